@@ -0,0 +1,58 @@
+package main
+
+import (
+	"Kasir-API/config"
+	"Kasir-API/database"
+	"Kasir-API/repositories"
+	"Kasir-API/services"
+	"Kasir-API/utils"
+	"flag"
+	"log"
+	"time"
+)
+
+// runCommand runs a maintenance command instead of the server, e.g.
+//
+//	./main rebuild-summaries -from 2024-01-01 -to 2024-12-31
+func runCommand(name string, args []string) {
+	switch name {
+	case "rebuild-summaries":
+		rebuildSummaries(args)
+	default:
+		log.Fatalf("❌ Unknown command %q, available: rebuild-summaries", name)
+	}
+}
+
+// rebuildSummaries recomputes the daily sales summaries, for all days or the given store-local dates
+func rebuildSummaries(args []string) {
+	flags := flag.NewFlagSet("rebuild-summaries", flag.ExitOnError)
+	fromValue := flags.String("from", "", "first day to rebuild (YYYY-MM-DD), defaults to the first sale")
+	toValue := flags.String("to", "", "last day to rebuild (YYYY-MM-DD), defaults to today")
+	_ = flags.Parse(args)
+
+	parseDay := func(name, value string) *time.Time {
+		if value == "" {
+			return nil
+		}
+		day, err := time.ParseInLocation(utils.DateLayout, value, config.StoreLocation())
+		if err != nil {
+			log.Fatalf("❌ Invalid -%s %q, use YYYY-MM-DD", name, value)
+		}
+		return &day
+	}
+	from, to := parseDay("from", *fromValue), parseDay("to", *toValue)
+	if from != nil && to != nil && from.After(*to) {
+		log.Fatal("❌ -from cannot be after -to")
+	}
+
+	database.ConnectDatabase()
+
+	service := services.NewSummaryService(repositories.NewSummaryRepository(database.GetDB()))
+	started := time.Now()
+	days, err := service.Rebuild(from, to)
+	if err != nil {
+		log.Fatalf("❌ Rebuilding summaries failed: %v", err)
+	}
+
+	log.Printf("✅ Rebuilt daily summaries for %d days in %v", days, time.Since(started).Round(time.Millisecond))
+}
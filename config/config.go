@@ -1,6 +1,11 @@
 package config
 
 import (
+	"log"
+	"sync"
+	"time"
+	_ "time/tzdata" // The container image has no zoneinfo
+
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
@@ -11,4 +16,32 @@ func Init() {
 	viper.SetDefault("GIN_MODE", "debug")
 	viper.SetDefault("PORT", "8080")
 	viper.SetDefault("DB_SSLMODE", "disable")
+	viper.SetDefault("LOW_STOCK_THRESHOLD", 5)
+	viper.SetDefault("STORE_TIMEZONE", "Asia/Jakarta")
+	viper.SetDefault("TAX_RATE", 0) // Percent included in selling prices, e.g. 11 for PPN
+	viper.SetDefault("REORDER_LEAD_TIME_DAYS", 7)
+	viper.SetDefault("REORDER_COVER_DAYS", 7)
+	viper.SetDefault("FORECAST_HISTORY_DAYS", 56)
+	viper.SetDefault("SUMMARY_MIN_DAYS", 31) // Reports spanning at least this many days read the daily summaries
+	viper.SetDefault("ABC_THRESHOLD_A", 80)  // Cumulative revenue percent covered by class A products
+	viper.SetDefault("ABC_THRESHOLD_B", 95)
+}
+
+var (
+	storeLocation     *time.Location
+	storeLocationOnce sync.Once
+)
+
+// StoreLocation returns the store's timezone, used to decide which day or hour a sale belongs to
+func StoreLocation() *time.Location {
+	storeLocationOnce.Do(func() {
+		name := viper.GetString("STORE_TIMEZONE")
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("⚠️ Warning: invalid STORE_TIMEZONE %q, using UTC: %v", name, err)
+			location = time.UTC
+		}
+		storeLocation = location
+	})
+	return storeLocation
 }
@@ -36,8 +36,8 @@ func ConnectDatabase() {
 		}
 
 		databaseURL = fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=Asia/Jakarta",
-			dbHost, dbPort, dbUser, dbPassword, dbName, dbSSLMode,
+			"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=%s",
+			dbHost, dbPort, dbUser, dbPassword, dbName, dbSSLMode, viper.GetString("STORE_TIMEZONE"),
 		)
 	}
 
@@ -72,13 +72,15 @@ func ConnectDatabase() {
 	log.Printf("📊 Connection Pool Stats: MaxOpen=%d, MaxIdle=%d", 300, 150)
 
 	// ==================== AUTO MIGRATE ====================
-	err = DB.AutoMigrate(&models.Category{}, &models.Product{}, &models.Transaction{}, &models.TransactionDetail{})
+	err = DB.AutoMigrate(&models.Category{}, &models.Product{}, &models.Transaction{}, &models.TransactionDetail{}, &models.StockMovement{}, &models.ProductBarcode{}, &models.ProductUnit{}, &models.ScaleBarcodeRule{}, &models.ProductPrice{}, &models.ZReport{}, &models.PurchaseList{}, &models.PurchaseListItem{}, &models.DailySalesSummary{}, &models.DailyProductSummary{})
 	if err != nil {
 		log.Printf("⚠️ Warning: AutoMigrate failed: %v", err)
 	} else {
 		log.Println("✅ Database migration completed")
 	}
 
+	createSearchIndexes()
+
 	// ==================== TEST CONNECTION & POOL ====================
 	testConnection()
 
@@ -86,6 +88,24 @@ func ConnectDatabase() {
 	go monitorConnectionPool()
 }
 
+// createSearchIndexes adds the trigram indexes used by fuzzy product search
+func createSearchIndexes() {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin (name gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_products_sku_trgm ON products USING gin (sku gin_trgm_ops)",
+	}
+
+	for _, statement := range statements {
+		if err := DB.Exec(statement).Error; err != nil {
+			log.Printf("⚠️ Warning: search index setup failed (%s): %v", statement, err)
+			return
+		}
+	}
+
+	log.Println("✅ Search indexes ready")
+}
+
 func testConnection() {
 	var result int
 	if err := DB.Raw("SELECT 1").Scan(&result).Error; err != nil {
@@ -1,49 +1,70 @@
 package handlers
 
 import (
-	"Kasir-API/database"
 	"Kasir-API/models"
+	"Kasir-API/services"
 	"Kasir-API/utils"
+	"errors"
 	"github.com/gin-gonic/gin"
-	_ "strconv"
+	"gorm.io/gorm"
+	"strconv"
 )
 
-// GetAllCategories - GET /categories
-func GetAllCategories(c *gin.Context) {
-	var categories []models.Category
+type CategoryHandler struct {
+	service *services.CategoryService
+}
+
+func NewCategoryHandler(service *services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{service: service}
+}
 
-	// Optimized: Select only necessary fields
-	if err := database.GetDB().Select("id", "name", "description", "created_at", "updated_at").Find(&categories).Error; err != nil {
-		utils.InternalServerError(c, "Failed to fetch categories", err.Error())
+// GetAll - GET /categories
+func (h *CategoryHandler) GetAll(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	categories, info, err := h.service.GetAll(c.Query("archived") == "true", page)
+	if err != nil {
+		respondListError(c, "Failed to fetch categories", err)
 		return
 	}
 
 	if len(categories) == 0 {
-		utils.Success(c, "No categories found", []interface{}{})
+		utils.Paginated(c, "No categories found", []interface{}{}, page, info)
 		return
 	}
 
-	utils.Success(c, "Categories retrieved successfully", categories)
+	utils.Paginated(c, "Categories retrieved successfully", categories, page, info)
 }
 
-// GetCategoryByID - GET /categories/{id}
-func GetCategoryByID(c *gin.Context) {
-	id := c.Param("id")
+// GetByID - GET /categories/{id}
+func (h *CategoryHandler) GetByID(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "category")
+	if !ok {
+		return
+	}
 
-	var category models.Category
-	if err := database.GetDB().First(&category, id).Error; err != nil {
-		utils.NotFound(c, "Category")
+	category, err := h.service.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Category")
+			return
+		}
+		utils.InternalServerError(c, "Failed to fetch category", err.Error())
 		return
 	}
 
 	utils.Success(c, "Category retrieved successfully", category)
 }
 
-// CreateCategory - POST /categories
-func CreateCategory(c *gin.Context) {
+// Create - POST /categories
+func (h *CategoryHandler) Create(c *gin.Context) {
 	var input struct {
 		Name        string `json:"name" binding:"required,min=3,max=100"`
 		Description string `json:"description" binding:"max=500"`
+		ParentID    *uint  `json:"parent_id" binding:"omitempty,gt=0"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -51,9 +72,17 @@ func CreateCategory(c *gin.Context) {
 		return
 	}
 
-	// Check if category name already exists
-	var existingCategory models.Category
-	if err := database.GetDB().Where("name = ?", input.Name).First(&existingCategory).Error; err == nil {
+	// Check if category name already exists (archived categories keep their name)
+	existingCategory, err := h.service.FindByName(input.Name)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.InternalServerError(c, "Failed to create category", err.Error())
+		return
+	}
+	if existingCategory != nil {
+		if existingCategory.DeletedAt.Valid {
+			utils.BadRequest(c, "Category name belongs to an archived category, restore it instead", nil)
+			return
+		}
 		utils.BadRequest(c, "Category name already exists", nil)
 		return
 	}
@@ -61,29 +90,38 @@ func CreateCategory(c *gin.Context) {
 	category := models.Category{
 		Name:        input.Name,
 		Description: input.Description,
+		ParentID:    input.ParentID,
 	}
 
-	if err := database.GetDB().Create(&category).Error; err != nil {
-		utils.InternalServerError(c, "Failed to create category", err.Error())
+	if err := h.service.Create(&category); err != nil {
+		respondParentError(c, "Failed to create category", err)
 		return
 	}
 
 	utils.Created(c, "Category created successfully", category)
 }
 
-// UpdateCategory - PUT /categories/{id}
-func UpdateCategory(c *gin.Context) {
-	id := c.Param("id")
+// Update - PUT /categories/{id}
+func (h *CategoryHandler) Update(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "category")
+	if !ok {
+		return
+	}
 
-	var category models.Category
-	if err := database.GetDB().First(&category, id).Error; err != nil {
-		utils.NotFound(c, "Category")
+	category, err := h.service.FindActive(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Category")
+			return
+		}
+		utils.InternalServerError(c, "Failed to fetch category", err.Error())
 		return
 	}
 
 	var input struct {
 		Name        string `json:"name" binding:"omitempty,min=3,max=100"`
 		Description string `json:"description" binding:"omitempty,max=500"`
+		ParentID    *uint  `json:"parent_id"` // 0 moves the category to the top level
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -93,8 +131,12 @@ func UpdateCategory(c *gin.Context) {
 
 	// Check if new name already exists (if provided and different from current)
 	if input.Name != "" && input.Name != category.Name {
-		var existingCategory models.Category
-		if err := database.GetDB().Where("name = ? AND id != ?", input.Name, id).First(&existingCategory).Error; err == nil {
+		existingCategory, err := h.service.FindByName(input.Name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.InternalServerError(c, "Failed to update category", err.Error())
+			return
+		}
+		if existingCategory != nil && existingCategory.ID != category.ID {
 			utils.BadRequest(c, "Category name already exists", nil)
 			return
 		}
@@ -105,30 +147,110 @@ func UpdateCategory(c *gin.Context) {
 		category.Description = input.Description
 	}
 
-	if err := database.GetDB().Save(&category).Error; err != nil {
-		utils.InternalServerError(c, "Failed to update category", err.Error())
+	if input.ParentID != nil {
+		if *input.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			category.ParentID = input.ParentID
+		}
+	}
+
+	if err := h.service.Save(category); err != nil {
+		respondParentError(c, "Failed to update category", err)
 		return
 	}
 
 	utils.Success(c, "Category updated successfully", category)
 }
 
-// DeleteCategory - DELETE /categories/{id}
-func DeleteCategory(c *gin.Context) {
-	id := c.Param("id")
+// GetTree - GET /categories/tree
+func (h *CategoryHandler) GetTree(c *gin.Context) {
+	tree, err := h.service.GetTree()
+	if err != nil {
+		utils.InternalServerError(c, "Failed to fetch category tree", err.Error())
+		return
+	}
+
+	utils.Success(c, "Category tree retrieved successfully", tree)
+}
+
+// respondParentError answers a failed category write, with 400 when the parent is invalid
+func respondParentError(c *gin.Context, message string, err error) {
+	if errors.Is(err, services.ErrInvalidParent) || errors.Is(err, services.ErrCategoryCycle) {
+		utils.BadRequest(c, err.Error(), nil)
+		return
+	}
+	utils.InternalServerError(c, message, err.Error())
+}
 
-	var category models.Category
-	if err := database.GetDB().First(&category, id).Error; err != nil {
-		utils.NotFound(c, "Category")
+// Delete - DELETE /categories/{id}
+// Archives the category by default, or removes the row with ?permanent=true. A category that
+// still has products or subcategories is refused unless ?target_category_id= is given, in which
+// case they are moved to the target in the same transaction.
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "category")
+	if !ok {
 		return
 	}
 
-	if err := database.GetDB().Delete(&category).Error; err != nil {
-		utils.InternalServerError(c, "Failed to delete category", err.Error())
+	var targetID *uint
+	if target := c.Query("target_category_id"); target != "" {
+		parsed, err := strconv.ParseUint(target, 10, 64)
+		if err != nil || parsed == 0 {
+			utils.BadRequest(c, "Invalid target_category_id", nil)
+			return
+		}
+		value := uint(parsed)
+		targetID = &value
+	}
+
+	permanent := c.Query("permanent") == "true"
+
+	result, err := h.service.Delete(id, targetID, permanent)
+	if err != nil {
+		var inUse *services.CategoryInUseError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFound(c, "Category")
+		case errors.As(err, &inUse):
+			utils.Conflict(c, err.Error(), gin.H{
+				"products":      inUse.Products,
+				"subcategories": inUse.Children,
+			})
+		case errors.Is(err, services.ErrInvalidTarget):
+			utils.BadRequest(c, err.Error(), nil)
+		case utils.IsForeignKeyError(err):
+			utils.Conflict(c, "Category is still referenced and can only be archived", nil)
+		default:
+			utils.InternalServerError(c, "Failed to delete category", err.Error())
+		}
+		return
+	}
+
+	message := "Category archived successfully"
+	if permanent {
+		message = "Category deleted permanently"
+	}
+
+	utils.Success(c, message, result)
+}
+
+// Restore - POST /categories/{id}/restore
+func (h *CategoryHandler) Restore(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "category")
+	if !ok {
+		return
+	}
+
+	category, err := h.service.Restore(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Archived category")
+			return
+		}
+		utils.InternalServerError(c, "Failed to restore category", err.Error())
 		return
 	}
 
-	utils.Success(c, "Category deleted successfully", gin.H{
-		"id": category.ID,
-	})
+	utils.Success(c, "Category restored successfully", category)
 }
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"Kasir-API/config"
+	"Kasir-API/models"
+	"Kasir-API/services"
+	"Kasir-API/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	service *services.ExportService
+}
+
+func NewExportHandler(service *services.ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// exportColumn is a column header in Indonesian and English
+type exportColumn struct {
+	ID string
+	EN string
+}
+
+func exportHeaders(columns []exportColumn, lang string) []string {
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.ID
+		if lang == utils.LangEN {
+			headers[i] = column.EN
+		}
+	}
+	return headers
+}
+
+var transactionExportColumns = []exportColumn{
+	{"ID Transaksi", "Transaction ID"},
+	{"Tanggal", "Date"},
+	{"Total Transaksi", "Transaction Total"},
+	{"ID Baris", "Line ID"},
+	{"ID Produk", "Product ID"},
+	{"Nama Produk", "Product Name"},
+	{"SKU", "SKU"},
+	{"Jumlah", "Quantity"},
+	{"Satuan Dasar", "Base Unit"},
+	{"Jumlah Jual", "Sold Quantity"},
+	{"Satuan Jual", "Sold Unit"},
+	{"Subtotal", "Subtotal"},
+	{"HPP", "COGS"},
+	{"Laba Kotor", "Gross Profit"},
+}
+
+// ExportTransactions - GET /export/transactions?start_date=2024-01-01&end_date=2024-12-31&format=xlsx&lang=id
+// One row per transaction line, repeating the transaction header fields.
+func (h *ExportHandler) ExportTransactions(c *gin.Context) {
+	format, lang, ok := parseExportOptions(c)
+	if !ok {
+		return
+	}
+
+	start, end, ok := parseReportRange(c, 1)
+	if !ok {
+		return
+	}
+
+	location := config.StoreLocation()
+	name := "transactions-" + exportRangeName(start, end)
+	streamExport(c, format, lang, name, transactionExportColumns, func(w utils.TableWriter) error {
+		return h.service.EachTransactionLine(start, end, func(line *models.TransactionExportLine) error {
+			return w.WriteRow(
+				line.TransactionID,
+				line.CreatedAt.In(location),
+				utils.Money(line.TotalAmount),
+				line.LineID,
+				line.ProductID,
+				line.ProductName,
+				line.SKU,
+				utils.Quantity(line.Quantity),
+				line.BaseUnit,
+				utils.Quantity(line.UnitQuantity),
+				line.Unit,
+				utils.Money(line.Subtotal),
+				utils.Money(line.CostSubtotal),
+				utils.Money(line.Subtotal-line.CostSubtotal),
+			)
+		})
+	})
+}
+
+var productReportExportColumns = []exportColumn{
+	{"ID Produk", "Product ID"},
+	{"Nama Produk", "Product Name"},
+	{"Terjual", "Quantity Sold"},
+	{"Pendapatan", "Revenue"},
+	{"HPP", "COGS"},
+	{"Laba Kotor", "Gross Profit"},
+	{"Margin Kotor (%)", "Gross Margin (%)"},
+}
+
+var categoryReportExportColumns = []exportColumn{
+	{"ID Kategori", "Category ID"},
+	{"Kategori", "Category"},
+	{"Terjual", "Quantity Sold"},
+	{"Pendapatan", "Revenue"},
+	{"HPP", "COGS"},
+	{"Laba Kotor", "Gross Profit"},
+	{"Margin Kotor (%)", "Gross Margin (%)"},
+}
+
+// ExportReport - GET /export/report?start_date=2024-01-01&end_date=2024-01-31&group_by=category&format=xlsx
+// group_by is product (default) or category, category_depth rolls categories up like GET /report.
+func (h *ExportHandler) ExportReport(c *gin.Context) {
+	format, lang, ok := parseExportOptions(c)
+	if !ok {
+		return
+	}
+
+	start, end, ok := parseReportRange(c, 1)
+	if !ok {
+		return
+	}
+
+	groupBy := c.DefaultQuery("group_by", "product")
+	if groupBy != "product" && groupBy != "category" {
+		utils.ValidationError(c, "Invalid group_by", "group_by must be product or category")
+		return
+	}
+
+	categoryDepth, err := strconv.Atoi(c.DefaultQuery("category_depth", "0"))
+	if err != nil || categoryDepth < 0 {
+		utils.ValidationError(c, "Invalid category_depth", "category_depth must be a whole number of 0 or more")
+		return
+	}
+
+	report, err := h.service.SalesReport(start, end, categoryDepth)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to generate report", err.Error())
+		return
+	}
+
+	total := "TOTAL"
+	name := "sales-report-" + exportRangeName(start, end)
+
+	if groupBy == "category" {
+		streamExport(c, format, lang, name, categoryReportExportColumns, func(w utils.TableWriter) error {
+			for _, row := range report.PerCategory {
+				if err := w.WriteRow(row.CategoryID, row.Path, utils.Quantity(row.QtySold), utils.Money(row.Revenue),
+					utils.Money(row.COGS), utils.Money(row.GrossProfit), utils.Percent(row.MarginPct)); err != nil {
+					return err
+				}
+			}
+			return w.WriteRow(nil, total, nil, utils.Money(report.TotalRevenue), utils.Money(report.TotalCOGS),
+				utils.Money(report.GrossProfit), utils.Percent(report.GrossMarginPct))
+		})
+		return
+	}
+
+	streamExport(c, format, lang, name, productReportExportColumns, func(w utils.TableWriter) error {
+		for _, row := range report.PerProduct {
+			if err := w.WriteRow(row.ProductID, row.Name, utils.Quantity(row.QtySold), utils.Money(row.Revenue),
+				utils.Money(row.COGS), utils.Money(row.GrossProfit), utils.Percent(row.MarginPct)); err != nil {
+				return err
+			}
+		}
+		return w.WriteRow(nil, total, nil, utils.Money(report.TotalRevenue), utils.Money(report.TotalCOGS),
+			utils.Money(report.GrossProfit), utils.Percent(report.GrossMarginPct))
+	})
+}
+
+var productExportColumns = []exportColumn{
+	{"ID Produk", "Product ID"},
+	{"Nama Produk", "Product Name"},
+	{"SKU", "SKU"},
+	{"PLU", "PLU"},
+	{"Barcode", "Barcodes"},
+	{"Kategori", "Category"},
+	{"Jenis Ukuran", "Measure Type"},
+	{"Satuan Dasar", "Base Unit"},
+	{"Harga Jual", "Selling Price"},
+	{"Harga Pokok", "Cost Price"},
+}
+
+// ExportProducts - GET /export/products?format=xlsx&lang=en
+func (h *ExportHandler) ExportProducts(c *gin.Context) {
+	format, lang, ok := parseExportOptions(c)
+	if !ok {
+		return
+	}
+
+	streamExport(c, format, lang, "products-"+exportToday(), productExportColumns, func(w utils.TableWriter) error {
+		return h.service.EachProduct(func(p *models.ProductExportRow) error {
+			return w.WriteRow(p.ID, p.Name, p.SKU, p.PLU, p.Barcodes, p.Category, p.MeasureType, p.BaseUnit,
+				utils.Money(p.Price), utils.Money(p.CostPrice))
+		})
+	})
+}
+
+var stockExportColumns = []exportColumn{
+	{"ID Produk", "Product ID"},
+	{"Nama Produk", "Product Name"},
+	{"SKU", "SKU"},
+	{"Kategori", "Category"},
+	{"Stok", "Stock"},
+	{"Satuan Dasar", "Base Unit"},
+	{"Harga Pokok", "Cost Price"},
+	{"Nilai Stok (HPP)", "Stock Value (Cost)"},
+	{"Harga Jual", "Selling Price"},
+	{"Nilai Stok (Jual)", "Stock Value (Retail)"},
+}
+
+// ExportStock - GET /export/stock?format=xlsx
+// Live stock levels valued at cost and retail price.
+func (h *ExportHandler) ExportStock(c *gin.Context) {
+	format, lang, ok := parseExportOptions(c)
+	if !ok {
+		return
+	}
+
+	streamExport(c, format, lang, "stock-"+exportToday(), stockExportColumns, func(w utils.TableWriter) error {
+		return h.service.EachProduct(func(p *models.ProductExportRow) error {
+			return w.WriteRow(p.ID, p.Name, p.SKU, p.Category, utils.Quantity(p.Stock), p.BaseUnit,
+				utils.Money(p.CostPrice), utils.Money(p.Stock*p.CostPrice), utils.Money(p.Price), utils.Money(p.Stock*p.Price))
+		})
+	})
+}
+
+// parseExportOptions reads format (csv or xlsx, default csv) and lang (id or en, default id)
+func parseExportOptions(c *gin.Context) (string, string, bool) {
+	format := c.DefaultQuery("format", utils.ExportCSV)
+	if format != utils.ExportCSV && format != utils.ExportXLSX {
+		utils.ValidationError(c, "Invalid format", "format must be csv or xlsx")
+		return "", "", false
+	}
+
+	lang := c.DefaultQuery("lang", utils.LangID)
+	if lang != utils.LangID && lang != utils.LangEN {
+		utils.ValidationError(c, "Invalid lang", "lang must be id or en")
+		return "", "", false
+	}
+
+	return format, lang, true
+}
+
+func exportRangeName(start, end time.Time) string {
+	location := config.StoreLocation()
+	first := start.In(location).Format(utils.DateLayout)
+	last := end.Add(-time.Nanosecond).In(location).Format(utils.DateLayout)
+	if first == last {
+		return first
+	}
+	return first + "_" + last
+}
+
+func exportToday() string {
+	return time.Now().In(config.StoreLocation()).Format(utils.DateLayout)
+}
+
+// exportResponseWriter sends the download headers with the first bytes of the file, so a failure
+// before any row was produced can still be answered with a JSON error
+type exportResponseWriter struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (w *exportResponseWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", `attachment; filename="`+w.filename+`"`)
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// streamExport writes a header row and the rows produced by fill straight to the response
+func streamExport(c *gin.Context, format, lang, name string, columns []exportColumn, fill func(w utils.TableWriter) error) {
+	out := &exportResponseWriter{c: c, contentType: utils.ExportContentType(format), filename: name + "." + format}
+
+	writer, err := utils.NewTableWriter(out, format, lang, name)
+	if err == nil {
+		err = writer.WriteHeader(exportHeaders(columns, lang))
+	}
+	if err == nil {
+		err = fill(writer)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		if !out.started {
+			utils.InternalServerError(c, "Failed to export "+name, err.Error())
+			return
+		}
+		// The file is already partly sent, all that is left is to stop and log it
+		log.Printf("export %s failed after streaming started: %v", name, err)
+		c.Abort()
+	}
+}
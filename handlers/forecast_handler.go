@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"Kasir-API/models"
+	"Kasir-API/services"
+	"Kasir-API/utils"
+	"errors"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ForecastHandler struct {
+	service *services.ForecastService
+}
+
+func NewForecastHandler(service *services.ForecastService) *ForecastHandler {
+	return &ForecastHandler{service: service}
+}
+
+// Forecast - GET /report/forecast?history_days=56&lead_time_days=7&cover_days=7&product_id=1&product_id=2
+func (h *ForecastHandler) Forecast(c *gin.Context) {
+	var request models.ForecastRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		utils.ValidationError(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	report, err := h.service.Forecast(request)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to generate forecast", err.Error())
+		return
+	}
+
+	utils.Success(c, "Forecast generated successfully", report)
+}
+
+// CreatePurchaseList - POST /purchase-lists/from-forecast
+// The body is optional and takes the same options as the forecast plus a note.
+func (h *ForecastHandler) CreatePurchaseList(c *gin.Context) {
+	var request models.PurchaseListFromForecastRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		utils.ValidationError(c, "Validation error", err.Error())
+		return
+	}
+
+	list, err := h.service.CreatePurchaseList(request)
+	if err != nil {
+		if errors.Is(err, services.ErrNothingToReorder) {
+			utils.BadRequest(c, err.Error(), nil)
+			return
+		}
+		utils.InternalServerError(c, "Failed to create purchase list", err.Error())
+		return
+	}
+
+	utils.Created(c, "Draft purchase list created successfully", list)
+}
+
+// GetPurchaseLists - GET /purchase-lists
+func (h *ForecastHandler) GetPurchaseLists(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	lists, info, err := h.service.GetPurchaseLists(page)
+	if err != nil {
+		respondListError(c, "Failed to fetch purchase lists", err)
+		return
+	}
+
+	utils.Paginated(c, "Purchase lists retrieved successfully", lists, page, info)
+}
+
+// GetPurchaseList - GET /purchase-lists/{id}
+func (h *ForecastHandler) GetPurchaseList(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "purchase list")
+	if !ok {
+		return
+	}
+
+	list, err := h.service.GetPurchaseList(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Purchase list")
+			return
+		}
+		utils.InternalServerError(c, "Failed to fetch purchase list", err.Error())
+		return
+	}
+
+	utils.Success(c, "Purchase list retrieved successfully", list)
+}
+
+// DeletePurchaseList - DELETE /purchase-lists/{id}
+func (h *ForecastHandler) DeletePurchaseList(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "purchase list")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePurchaseList(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Purchase list")
+			return
+		}
+		utils.InternalServerError(c, "Failed to delete purchase list", err.Error())
+		return
+	}
+
+	utils.Success(c, "Purchase list deleted successfully", gin.H{
+		"id": id,
+	})
+}
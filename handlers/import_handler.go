@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"Kasir-API/services"
+	"Kasir-API/utils"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportFileSize limits uploads, a few thousand products fit easily in this
+const maxImportFileSize = 10 << 20
+
+type ImportHandler struct {
+	service *services.ImportService
+}
+
+func NewImportHandler(service *services.ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// ImportProducts - POST /products/import?dry_run=true
+// Multipart form with the CSV or XLSX in "file" and optionally a JSON "mapping" of field to column
+// header, e.g. {"name":"Item","price":"Retail"}. Products are upserted by SKU, or by name without one.
+func (h *ImportHandler) ImportProducts(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true" || c.PostForm("dry_run") == "true"
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		utils.ValidationError(c, "Missing file", "upload the CSV or XLSX file in the file field")
+		return
+	}
+	if header.Size > maxImportFileSize {
+		utils.ValidationError(c, "File too large", "the file must be at most 10 MB")
+		return
+	}
+
+	format := c.DefaultQuery("format", utils.ImportFormat(header.Filename))
+	if format != utils.ExportCSV && format != utils.ExportXLSX {
+		utils.ValidationError(c, "Invalid format", "upload a .csv or .xlsx file or set format to csv or xlsx")
+		return
+	}
+
+	var mapping map[string]string
+	if value := c.PostForm("mapping"); value != "" {
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			utils.ValidationError(c, "Invalid mapping", "mapping must be a JSON object of field to column header")
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		utils.InternalServerError(c, "Failed to read file", err.Error())
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImportFileSize))
+	if err != nil {
+		utils.InternalServerError(c, "Failed to read file", err.Error())
+		return
+	}
+
+	table, err := utils.ReadTable(data, format)
+	if err != nil {
+		utils.ValidationError(c, "Unreadable file", err.Error())
+		return
+	}
+
+	result, err := h.service.ImportProducts(table, mapping, dryRun)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidImport) {
+			utils.ValidationError(c, "Invalid import file", err.Error())
+			return
+		}
+		utils.InternalServerError(c, "Failed to import products", err.Error())
+		return
+	}
+
+	switch {
+	case dryRun:
+		utils.Success(c, "Import checked, nothing was saved", result)
+	case !result.Applied:
+		utils.ValidationError(c, "Some rows are invalid, nothing was imported", result)
+	default:
+		utils.Success(c, "Products imported successfully", result)
+	}
+}
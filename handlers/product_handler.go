@@ -5,10 +5,24 @@ import (
 	"Kasir-API/models"
 	"Kasir-API/services"
 	"Kasir-API/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// errProductInUse stops a permanent delete of a product that has sales, stock movements or purchase list items
+var errProductInUse = errors.New("product is in use")
+
+// errPrecisionTooLow stops lowering the quantity precision below what the stock or unit factors need
+var errPrecisionTooLow = errors.New("quantity_precision is too low")
+
 type ProductHandler struct {
 	service *services.ProductService
 }
@@ -18,28 +32,309 @@ func NewProductHandler(service *services.ProductService) *ProductHandler {
 }
 
 func (h *ProductHandler) GetAll(c *gin.Context) {
-	// Ambil data 'name' dari query parameter
-	name := c.Query("name")
+	// Ambil filter, pencarian dan urutan dari query parameter
+	var filter models.ProductFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.ValidationError(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		utils.ValidationError(c, "Invalid query parameters", "min_price cannot be greater than max_price")
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
 
-	products, err := h.service.GetAll(name)
+	products, info, err := h.service.GetAll(filter, page)
 	if err != nil {
-		utils.InternalServerError(c, "Failed to fetch products", err.Error())
+		respondListError(c, "Failed to fetch products", err)
 		return
 	}
 
 	if len(products) == 0 {
-		utils.Success(c, "No products found", []interface{}{})
+		utils.Paginated(c, "No products found", []interface{}{}, page, info)
+		return
+	}
+
+	utils.Paginated(c, "Products retrieved successfully", products, page, info)
+}
+
+// ReceiveStock - POST /products/{id}/receive
+func (h *ProductHandler) ReceiveStock(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	var request models.ReceiveStockRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.ValidationError(c, "Validation error", err.Error())
+		return
+	}
+
+	product, err := h.service.ReceiveStock(id, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFound(c, "Product")
+		case errors.Is(err, services.ErrUnknownUnit), errors.Is(err, services.ErrInvalidQuantity):
+			utils.BadRequest(c, err.Error(), nil)
+		default:
+			utils.InternalServerError(c, "Failed to receive stock", err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, "Stock received successfully", product)
+}
+
+// GetUnits - GET /products/{id}/units
+func (h *ProductHandler) GetUnits(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	units, info, err := h.service.GetUnits(id, page)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Product")
+			return
+		}
+		respondListError(c, "Failed to fetch units", err)
+		return
+	}
+
+	utils.Paginated(c, "Units retrieved successfully", units, page, info)
+}
+
+// CreateUnit - POST /products/{id}/units
+func (h *ProductHandler) CreateUnit(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	var request models.ProductUnitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.ValidationError(c, "Validation error", err.Error())
+		return
+	}
+
+	unit, err := h.service.CreateUnit(id, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFound(c, "Product")
+		case errors.Is(err, services.ErrDuplicateUnit):
+			utils.BadRequest(c, err.Error(), nil)
+		default:
+			utils.InternalServerError(c, "Failed to create unit", err.Error())
+		}
+		return
+	}
+
+	utils.Created(c, "Unit created successfully", unit)
+}
+
+// UpdateUnit - PUT /products/{id}/units/{unit_id}
+func (h *ProductHandler) UpdateUnit(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+	unitID, ok := parseIDParam(c, "unit_id", "unit")
+	if !ok {
+		return
+	}
+
+	var request models.ProductUnitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.ValidationError(c, "Validation error", err.Error())
+		return
+	}
+
+	unit, err := h.service.UpdateUnit(id, unitID, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFound(c, "Unit")
+		case errors.Is(err, services.ErrDuplicateUnit):
+			utils.BadRequest(c, err.Error(), nil)
+		default:
+			utils.InternalServerError(c, "Failed to update unit", err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, "Unit updated successfully", unit)
+}
+
+// DeleteUnit - DELETE /products/{id}/units/{unit_id}
+func (h *ProductHandler) DeleteUnit(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+	unitID, ok := parseIDParam(c, "unit_id", "unit")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteUnit(id, unitID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Unit")
+			return
+		}
+		utils.InternalServerError(c, "Failed to delete unit", err.Error())
+		return
+	}
+
+	utils.Success(c, "Unit deleted successfully", gin.H{
+		"id": unitID,
+	})
+}
+
+// GetPrices - GET /products/{id}/prices
+func (h *ProductHandler) GetPrices(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	prices, info, err := h.service.GetPrices(id, page)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Product")
+			return
+		}
+		respondListError(c, "Failed to fetch price history", err)
+		return
+	}
+
+	utils.Paginated(c, "Price history retrieved successfully", prices, page, info)
+}
+
+// SchedulePrice - POST /products/{id}/prices
+func (h *ProductHandler) SchedulePrice(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	var request models.ProductPriceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.ValidationError(c, "Validation error", err.Error())
+		return
+	}
+
+	price, err := h.service.SchedulePrice(id, request)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Product")
+			return
+		}
+		utils.InternalServerError(c, "Failed to schedule price", err.Error())
 		return
 	}
 
-	utils.Success(c, "Products retrieved successfully", products)
+	message := "Price scheduled successfully"
+	if price.Applied {
+		message = "Price updated successfully"
+	}
+
+	utils.Created(c, message, price)
+}
+
+// CancelPrice - DELETE /products/{id}/prices/{price_id}
+func (h *ProductHandler) CancelPrice(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+	priceID, ok := parseIDParam(c, "price_id", "price")
+	if !ok {
+		return
+	}
+
+	if err := h.service.CancelPrice(id, priceID); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFound(c, "Price")
+		case errors.Is(err, services.ErrPriceAlreadyEffective):
+			utils.BadRequest(c, err.Error(), nil)
+		default:
+			utils.InternalServerError(c, "Failed to cancel price", err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, "Scheduled price cancelled successfully", gin.H{
+		"id": priceID,
+	})
+}
+
+// GetByBarcode - GET /products/barcode/{code}
+func (h *ProductHandler) GetByBarcode(c *gin.Context) {
+	result, err := h.service.Scan(c.Param("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFound(c, "Product")
+		case errors.Is(err, services.ErrInvalidBarcode):
+			utils.BadRequest(c, err.Error(), nil)
+		default:
+			utils.InternalServerError(c, "Failed to fetch product", err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, "Product retrieved successfully", result)
+}
+
+// GenerateLabels - POST /products/labels
+func (h *ProductHandler) GenerateLabels(c *gin.Context) {
+	var request models.LabelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.ValidationError(c, "Validation error", err.Error())
+		return
+	}
+
+	content, contentType, err := h.service.GenerateLabels(request)
+	if err != nil {
+		utils.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	extension := models.LabelFormatPDF
+	if contentType == "image/svg+xml" {
+		extension = models.LabelFormatSVG
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=labels."+extension)
+	c.Data(http.StatusOK, contentType, content)
 }
 
 func GetProductByID(c *gin.Context) {
 	id := c.Param("id")
 
 	var product models.Product
-	if err := database.GetDB().Preload("Category").First(&product, id).Error; err != nil {
+	// Unscoped so archived products referenced by old transactions can still be resolved
+	if err := database.GetDB().Unscoped().Preload("Category").Preload("Barcodes").Preload("Units").First(&product, id).Error; err != nil {
 		utils.NotFound(c, "Product")
 		return
 	}
@@ -50,10 +345,17 @@ func GetProductByID(c *gin.Context) {
 // handlers/product_handler.go
 func CreateProduct(c *gin.Context) {
 	var input struct {
-		Name       string  `json:"name" binding:"required,min=3,max=100"`
-		Price      float64 `json:"price" binding:"required,gt=0"`
-		Stock      int     `json:"stock" binding:"required,gte=0"`
-		CategoryID uint    `json:"category_id" binding:"required,gt=0"`
+		Name              string   `json:"name" binding:"required,min=3,max=100"`
+		SKU               string   `json:"sku" binding:"omitempty,max=64"`
+		PLU               string   `json:"plu" binding:"omitempty,numeric,max=6"`
+		Barcodes          []string `json:"barcodes" binding:"omitempty,dive,required"`
+		Price             float64  `json:"price" binding:"required,gt=0"`
+		CostPrice         float64  `json:"cost_price" binding:"gte=0"`
+		Stock             float64  `json:"stock" binding:"required,gte=0"`
+		BaseUnit          string   `json:"base_unit" binding:"omitempty,max=20"`
+		MeasureType       string   `json:"measure_type" binding:"omitempty,oneof=count weight measure"`
+		QuantityPrecision *int     `json:"quantity_precision" binding:"omitempty,min=0,max=3"`
+		CategoryID        uint     `json:"category_id" binding:"required,gt=0"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -68,23 +370,89 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
+	if input.SKU != "" {
+		if err := checkSKU(input.SKU, 0); err != nil {
+			utils.BadRequest(c, err.Error(), nil)
+			return
+		}
+	}
+
+	if err := checkBarcodes(input.Barcodes, 0); err != nil {
+		utils.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	plu := strings.TrimLeft(input.PLU, "0")
+	if plu != "" {
+		var existingProduct models.Product
+		if err := database.GetDB().Unscoped().Where("plu = ?", plu).First(&existingProduct).Error; err == nil {
+			utils.BadRequest(c, "PLU already exists", nil)
+			return
+		}
+	}
+
+	// Weighed and measured items default to 3 decimals, counted items to whole numbers
+	precision := 0
+	if input.MeasureType == models.MeasureWeight || input.MeasureType == models.MeasureMeasure {
+		precision = models.MaxQuantityPrecision
+	}
+	if input.QuantityPrecision != nil {
+		precision = *input.QuantityPrecision
+	}
+
+	if utils.RoundTo(input.Stock, precision) != input.Stock {
+		utils.BadRequest(c, "Stock has more decimals than quantity_precision allows", nil)
+		return
+	}
+
 	// Buat pointer untuk CategoryID
 	//categoryIDPtr := &input.CategoryID
 
+	now := time.Now()
 	product := models.Product{
-		Name:       input.Name,
-		Price:      input.Price,
-		Stock:      input.Stock,
-		CategoryID: input.CategoryID, // <-- SEKARANG pakai pointer
+		Name:           input.Name,
+		PriceChangedAt: &now,
+		Price:          input.Price,
+		CostPrice:      input.CostPrice,
+		Stock:          input.Stock,
+		CategoryID:     input.CategoryID, // <-- SEKARANG pakai pointer
 	}
 
+	if input.SKU != "" {
+		product.SKU = &input.SKU
+	}
+
+	if plu != "" {
+		product.PLU = &plu
+	}
+
+	if input.BaseUnit != "" {
+		product.BaseUnit = input.BaseUnit
+	}
+
+	if input.MeasureType != "" {
+		product.MeasureType = input.MeasureType
+	}
+	product.QuantityPrecision = precision
+
+	for _, code := range input.Barcodes {
+		product.Barcodes = append(product.Barcodes, models.ProductBarcode{Code: code})
+	}
+
+	// Start the price history with the initial price
+	product.Prices = []models.ProductPrice{{Price: input.Price, EffectiveFrom: now, Applied: true}}
+
 	if err := database.GetDB().Create(&product).Error; err != nil {
+		if utils.IsUniqueViolation(err) {
+			utils.Conflict(c, "SKU, PLU or barcode is already used by another product", nil)
+			return
+		}
 		utils.InternalServerError(c, "Failed to create product", err.Error())
 		return
 	}
 
 	// Preload category untuk response
-	if err := database.GetDB().Preload("Category").First(&product, product.ID).Error; err != nil {
+	if err := database.GetDB().Preload("Category").Preload("Barcodes").First(&product, product.ID).Error; err != nil {
 		utils.InternalServerError(c, "Failed to fetch created product", err.Error())
 		return
 	}
@@ -107,10 +475,17 @@ func UpdateProduct(c *gin.Context) {
 	}
 
 	var input struct {
-		Name       string  `json:"name" binding:"omitempty,min=3,max=100"`
-		Price      float64 `json:"price" binding:"omitempty,gt=0"`
-		Stock      *int    `json:"stock" binding:"omitempty,gte=0"`
-		CategoryID *uint   `json:"category_id" binding:"omitempty,gt=0"` // <-- MASIH pointer
+		Name              string    `json:"name" binding:"omitempty,min=3,max=100"`
+		SKU               *string   `json:"sku" binding:"omitempty,max=64"`
+		PLU               *string   `json:"plu" binding:"omitempty,numeric,max=6"`
+		Barcodes          *[]string `json:"barcodes" binding:"omitempty,dive,required"`
+		Price             float64   `json:"price" binding:"omitempty,gt=0"`
+		CostPrice         *float64  `json:"cost_price" binding:"omitempty,gte=0"`
+		Stock             *float64  `json:"stock" binding:"omitempty,gte=0"`
+		BaseUnit          string    `json:"base_unit" binding:"omitempty,max=20"`
+		MeasureType       string    `json:"measure_type" binding:"omitempty,oneof=count weight measure"`
+		QuantityPrecision *int      `json:"quantity_precision" binding:"omitempty,min=0,max=3"`
+		CategoryID        *uint     `json:"category_id" binding:"omitempty,gt=0"` // <-- MASIH pointer
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -125,14 +500,77 @@ func UpdateProduct(c *gin.Context) {
 		updates["name"] = input.Name
 	}
 
-	if input.Price != 0 {
+	if input.SKU != nil {
+		if *input.SKU == "" {
+			updates["sku"] = nil
+		} else {
+			if err := checkSKU(*input.SKU, product.ID); err != nil {
+				utils.BadRequest(c, err.Error(), nil)
+				return
+			}
+			updates["sku"] = *input.SKU
+		}
+	}
+
+	if input.PLU != nil {
+		plu := strings.TrimLeft(*input.PLU, "0")
+		if plu == "" {
+			updates["plu"] = nil
+		} else {
+			var existingProduct models.Product
+			if err := database.GetDB().Unscoped().Where("plu = ? AND id != ?", plu, product.ID).First(&existingProduct).Error; err == nil {
+				utils.BadRequest(c, "PLU already exists", nil)
+				return
+			}
+			updates["plu"] = plu
+		}
+	}
+
+	if input.Barcodes != nil {
+		if err := checkBarcodes(*input.Barcodes, product.ID); err != nil {
+			utils.BadRequest(c, err.Error(), nil)
+			return
+		}
+	}
+
+	now := time.Now()
+	priceChanged := input.Price != 0 && input.Price != product.Price
+	if priceChanged {
 		updates["price"] = input.Price
+		updates["price_changed_at"] = now
+	}
+
+	if input.CostPrice != nil {
+		updates["cost_price"] = *input.CostPrice
+	}
+
+	precision := product.QuantityPrecision
+	if input.QuantityPrecision != nil {
+		precision = *input.QuantityPrecision
+		updates["quantity_precision"] = precision
+	}
+
+	if input.MeasureType != "" {
+		updates["measure_type"] = input.MeasureType
+		// Switching to counted items drops the decimals unless a precision is given, like on create
+		if input.MeasureType == models.MeasureCount && input.MeasureType != product.MeasureType && input.QuantityPrecision == nil {
+			precision = 0
+			updates["quantity_precision"] = precision
+		}
 	}
 
 	if input.Stock != nil {
+		if utils.RoundTo(*input.Stock, precision) != *input.Stock {
+			utils.BadRequest(c, "Stock has more decimals than quantity_precision allows", nil)
+			return
+		}
 		updates["stock"] = *input.Stock
 	}
 
+	if input.BaseUnit != "" {
+		updates["base_unit"] = input.BaseUnit
+	}
+
 	if input.CategoryID != nil {
 		// Cek apakah kategori ada
 		var category models.Category
@@ -144,14 +582,82 @@ func UpdateProduct(c *gin.Context) {
 		updates["category_id"] = input.CategoryID
 	}
 
-	// Update product
-	if err := database.GetDB().Model(&product).Updates(updates).Error; err != nil {
+	// Manual stock corrections are recorded so past stock levels can be reconstructed
+	var adjustment *models.StockMovement
+	if input.Stock != nil && *input.Stock != product.Stock {
+		adjustment = &models.StockMovement{
+			ProductID:  product.ID,
+			Type:       models.StockMovementAdjustment,
+			Quantity:   utils.RoundTo(*input.Stock-product.Stock, models.MaxQuantityPrecision),
+			UnitCost:   product.CostPrice,
+			StockAfter: *input.Stock,
+			CostAfter:  product.CostPrice,
+		}
+	}
+
+	// Update product and replace its barcodes in one transaction
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		if precision < product.QuantityPrecision {
+			// Locking the row keeps sales and receipts from adding decimals between the check and the update
+			var current models.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Units").First(&current, product.ID).Error; err != nil {
+				return err
+			}
+			stock := current.Stock
+			if input.Stock != nil {
+				stock = *input.Stock
+			}
+			if err := checkPrecision(current.Units, stock, precision); err != nil {
+				return err
+			}
+		}
+
+		if len(updates) > 0 {
+			if err := tx.Model(&product).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if adjustment != nil {
+			if err := tx.Create(adjustment).Error; err != nil {
+				return err
+			}
+		}
+
+		if priceChanged {
+			if err := tx.Create(&models.ProductPrice{ProductID: product.ID, Price: input.Price, EffectiveFrom: now, Applied: true}).Error; err != nil {
+				return err
+			}
+		}
+
+		if input.Barcodes != nil {
+			if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductBarcode{}).Error; err != nil {
+				return err
+			}
+			for _, code := range *input.Barcodes {
+				if err := tx.Create(&models.ProductBarcode{ProductID: product.ID, Code: code}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errPrecisionTooLow) {
+			utils.BadRequest(c, err.Error(), nil)
+			return
+		}
+		if utils.IsUniqueViolation(err) {
+			utils.Conflict(c, "SKU, PLU or barcode is already used by another product", nil)
+			return
+		}
 		utils.InternalServerError(c, "Failed to update product", err.Error())
 		return
 	}
 
 	// Reload dengan category
-	if err := database.GetDB().Preload("Category").First(&product, product.ID).Error; err != nil {
+	if err := database.GetDB().Preload("Category").Preload("Barcodes").First(&product, product.ID).Error; err != nil {
 		utils.InternalServerError(c, "Failed to fetch updated product", err.Error())
 		return
 	}
@@ -164,21 +670,188 @@ func UpdateProduct(c *gin.Context) {
 	utils.Success(c, "Product updated successfully", product)
 }
 
+// DeleteProduct - DELETE /products/{id}
+// Archives the product by default. With ?permanent=true the row is removed, which is refused
+// while sales, stock movements or purchase lists reference it, since they are the product's audit trail.
 func DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 
 	var product models.Product
-	if err := database.GetDB().First(&product, id).Error; err != nil {
+	if err := database.GetDB().Unscoped().First(&product, id).Error; err != nil {
 		utils.NotFound(c, "Product")
 		return
 	}
 
-	if err := database.GetDB().Delete(&product).Error; err != nil {
-		utils.InternalServerError(c, "Failed to delete product", err.Error())
+	if c.Query("permanent") != "true" {
+		if err := database.GetDB().Delete(&product).Error; err != nil {
+			utils.InternalServerError(c, "Failed to archive product", err.Error())
+			return
+		}
+
+		utils.Success(c, "Product archived successfully", gin.H{
+			"id": product.ID,
+		})
 		return
 	}
 
-	utils.Success(c, "Product deleted successfully", gin.H{
+	var sales, movements, purchaseItems int64
+	err := database.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Locking the row makes checkouts and stock changes of this product wait for the delete
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, product.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.TransactionDetail{}).Where("product_id = ?", product.ID).Count(&sales).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.StockMovement{}).Where("product_id = ?", product.ID).Count(&movements).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PurchaseListItem{}).Where("product_id = ?", product.ID).Count(&purchaseItems).Error; err != nil {
+			return err
+		}
+		if sales > 0 || movements > 0 || purchaseItems > 0 {
+			return errProductInUse
+		}
+
+		// Barcodes, units and prices belong to the product and go with it
+		for _, owned := range []interface{}{&models.ProductBarcode{}, &models.ProductUnit{}, &models.ProductPrice{}} {
+			if err := tx.Where("product_id = ?", product.ID).Delete(owned).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&product).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errProductInUse):
+			utils.Conflict(c, "Product has sales, stock movements or purchase list items and can only be archived", gin.H{
+				"sales":               sales,
+				"stock_movements":     movements,
+				"purchase_list_items": purchaseItems,
+			})
+		case utils.IsForeignKeyError(err):
+			utils.Conflict(c, "Product is still referenced and can only be archived", nil)
+		default:
+			utils.InternalServerError(c, "Failed to delete product", err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, "Product deleted permanently", gin.H{
 		"id": product.ID,
 	})
 }
+
+// RestoreProduct - POST /products/{id}/restore
+func RestoreProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	var product models.Product
+	if err := database.GetDB().Unscoped().Where("deleted_at IS NOT NULL").First(&product, id).Error; err != nil {
+		utils.NotFound(c, "Archived product")
+		return
+	}
+
+	if err := database.GetDB().Unscoped().Model(&product).Update("deleted_at", nil).Error; err != nil {
+		utils.InternalServerError(c, "Failed to restore product", err.Error())
+		return
+	}
+
+	if err := database.GetDB().Preload("Category").Preload("Barcodes").First(&product, product.ID).Error; err != nil {
+		utils.InternalServerError(c, "Failed to fetch restored product", err.Error())
+		return
+	}
+
+	utils.Success(c, "Product restored successfully", product)
+}
+
+// checkBarcodes validates barcode check digits and makes sure none is used by another product
+func checkBarcodes(codes []string, productID uint) error {
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if err := utils.ValidateBarcode(code); err != nil {
+			return err
+		}
+		if seen[code] {
+			return fmt.Errorf("duplicate barcode %s", code)
+		}
+		seen[code] = true
+	}
+
+	if len(codes) == 0 {
+		return nil
+	}
+
+	var existing models.ProductBarcode
+	err := database.GetDB().Where("code IN ? AND product_id != ?", codes, productID).First(&existing).Error
+	if err == nil {
+		return fmt.Errorf("barcode %s already exists", existing.Code)
+	}
+
+	// Scans look codes up as barcode or SKU, so a barcode must not be another product's SKU
+	var owner models.Product
+	err = database.GetDB().Unscoped().Select("id", "sku").Where("sku IN ? AND id != ?", codes, productID).First(&owner).Error
+	if err == nil {
+		return fmt.Errorf("barcode %s is already the SKU of another product", *owner.SKU)
+	}
+
+	return nil
+}
+
+// checkPrecision makes sure the stock and every unit's conversion factor fit the given quantity precision
+func checkPrecision(units []models.ProductUnit, stock float64, precision int) error {
+	if utils.RoundTo(stock, precision) != stock {
+		return fmt.Errorf("%w: stock %v has more decimals, correct the stock first", errPrecisionTooLow, stock)
+	}
+	for _, unit := range units {
+		if utils.RoundTo(unit.ConversionFactor, precision) != unit.ConversionFactor {
+			return fmt.Errorf("%w: unit %s converts to %v base units", errPrecisionTooLow, unit.Name, unit.ConversionFactor)
+		}
+	}
+	return nil
+}
+
+// checkSKU makes sure a SKU is used neither as SKU nor as barcode by another product
+func checkSKU(sku string, productID uint) error {
+	var existingProduct models.Product
+	if err := database.GetDB().Unscoped().Where("sku = ? AND id != ?", sku, productID).First(&existingProduct).Error; err == nil {
+		return errors.New("SKU already exists")
+	}
+
+	var existing models.ProductBarcode
+	if err := database.GetDB().Where("code = ? AND product_id != ?", sku, productID).First(&existing).Error; err == nil {
+		return fmt.Errorf("SKU %s is already a barcode of another product", sku)
+	}
+
+	return nil
+}
+
+// parseIDParam reads a numeric path parameter and responds with 400 when it is invalid
+func parseIDParam(c *gin.Context, name, resource string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		utils.BadRequest(c, "Invalid "+resource+" ID", nil)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parsePage reads the pagination query parameters, responding with 422 when they are invalid
+func parsePage(c *gin.Context) (models.Page, bool) {
+	page, err := utils.ParsePagination(c)
+	if err != nil {
+		utils.ValidationError(c, "Invalid pagination parameters", err.Error())
+		return page, false
+	}
+	return page, true
+}
+
+// respondListError answers a failed list query, with 422 when the cursor's row is gone
+func respondListError(c *gin.Context, message string, err error) {
+	if errors.Is(err, utils.ErrStaleCursor) {
+		utils.ValidationError(c, "Invalid pagination parameters", err.Error())
+		return
+	}
+	utils.InternalServerError(c, message, err.Error())
+}
@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"Kasir-API/config"
+	"Kasir-API/models"
+	"Kasir-API/services"
+	"Kasir-API/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReportHandler struct {
+	service *services.ReportService
+}
+
+func NewReportHandler(service *services.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+// TimeSeries - GET /report/timeseries?interval=day&start_date=2024-01-01&end_date=2024-01-31
+func (h *ReportHandler) TimeSeries(c *gin.Context) {
+	interval := c.DefaultQuery("interval", models.IntervalDay)
+
+	// Hourly charts default to today, the others to the last 30 days
+	defaultDays := 30
+	if interval == models.IntervalHour {
+		defaultDays = 1
+	}
+
+	start, end, ok := parseReportRange(c, defaultDays)
+	if !ok {
+		return
+	}
+
+	series, err := h.service.TimeSeries(interval, start, end)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInterval) || errors.Is(err, services.ErrRangeTooLarge) {
+			utils.BadRequest(c, err.Error(), nil)
+			return
+		}
+		utils.InternalServerError(c, "Failed to generate time series", err.Error())
+		return
+	}
+
+	utils.Success(c, "Time series generated successfully", series)
+}
+
+// Heatmap - GET /report/heatmap?start_date=2024-01-01&end_date=2024-01-31
+func (h *ReportHandler) Heatmap(c *gin.Context) {
+	start, end, ok := parseReportRange(c, 28)
+	if !ok {
+		return
+	}
+
+	heatmap, err := h.service.Heatmap(start, end)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to generate heatmap", err.Error())
+		return
+	}
+
+	utils.Success(c, "Heatmap generated successfully", heatmap)
+}
+
+// parseReportRange reads start_date and end_date (inclusive, YYYY-MM-DD) in the store timezone,
+// responding with 422 when they are malformed or reversed
+func parseReportRange(c *gin.Context, defaultDays int) (time.Time, time.Time, bool) {
+	dateRange, err := utils.ParseDateRange(c.Query("start_date"), c.Query("end_date"), config.StoreLocation(), defaultDays)
+	if err != nil {
+		utils.ValidationError(c, "Invalid date range", err.Error())
+		return time.Time{}, time.Time{}, false
+	}
+	return dateRange.Start, dateRange.End, true
+}
+
+// XReport - GET /report/x?format=text
+func (h *ReportHandler) XReport(c *gin.Context) {
+	report, err := h.service.XReport()
+	if err != nil {
+		utils.InternalServerError(c, "Failed to generate X-report", err.Error())
+		return
+	}
+
+	if c.Query("format") == "text" {
+		c.String(http.StatusOK, utils.RenderRegisterReport(report.Type, 0, report.RegisterTotals, config.StoreLocation()))
+		return
+	}
+
+	utils.Success(c, "X-report generated successfully", report)
+}
+
+// CloseDay - POST /report/z
+// Stores an immutable Z-report for all sales since the previous one.
+func (h *ReportHandler) CloseDay(c *gin.Context) {
+	report, err := h.service.CloseDay()
+	if err != nil {
+		utils.InternalServerError(c, "Failed to close the day", err.Error())
+		return
+	}
+
+	if c.Query("format") == "text" {
+		c.String(http.StatusCreated, renderZReport(report))
+		return
+	}
+
+	utils.Created(c, "Z-report created successfully", report)
+}
+
+// GetZReports - GET /report/z
+func (h *ReportHandler) GetZReports(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	reports, info, err := h.service.GetZReports(page)
+	if err != nil {
+		respondListError(c, "Failed to fetch Z-reports", err)
+		return
+	}
+
+	utils.Paginated(c, "Z-reports retrieved successfully", reports, page, info)
+}
+
+// GetZReport - GET /report/z/{id}?format=text
+func (h *ReportHandler) GetZReport(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Z-report")
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetZReport(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Z-report")
+			return
+		}
+		utils.InternalServerError(c, "Failed to fetch Z-report", err.Error())
+		return
+	}
+
+	if c.Query("format") == "text" {
+		c.String(http.StatusOK, renderZReport(report))
+		return
+	}
+
+	utils.Success(c, "Z-report retrieved successfully", report)
+}
+
+func renderZReport(report *models.ZReport) string {
+	return utils.RenderRegisterReport(models.RegisterReportZ, report.Number, report.RegisterTotals, config.StoreLocation())
+}
+
+// InventoryValuation - GET /report/inventory-valuation?as_of=2024-01-31&format=xlsx&lang=en
+// as_of values the stock at the close of that day in the store timezone, without it the live stock is used.
+// format=csv or xlsx downloads the product lines like the other exports instead of returning JSON.
+func (h *ReportHandler) InventoryValuation(c *gin.Context) {
+	var asOf *time.Time
+	if value := c.Query("as_of"); value != "" {
+		day, err := time.ParseInLocation(utils.DateLayout, value, config.StoreLocation())
+		if err != nil {
+			utils.ValidationError(c, "Invalid as_of", "as_of must be a valid date in YYYY-MM-DD format")
+			return
+		}
+		closing := day.AddDate(0, 0, 1)
+		asOf = &closing
+	}
+
+	export := c.Query("format") != ""
+	var format, lang string
+	if export {
+		var ok bool
+		if format, lang, ok = parseExportOptions(c); !ok {
+			return
+		}
+	}
+
+	valuation, err := h.service.InventoryValuation(asOf)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to generate inventory valuation", err.Error())
+		return
+	}
+
+	if export {
+		exportInventoryValuation(c, format, lang, valuation)
+		return
+	}
+
+	utils.Success(c, "Inventory valuation generated successfully", valuation)
+}
+
+var inventoryValuationExportColumns = []exportColumn{
+	{"ID Produk", "Product ID"},
+	{"Nama Produk", "Product Name"},
+	{"SKU", "SKU"},
+	{"Kategori", "Category"},
+	{"Stok", "Stock"},
+	{"Satuan Dasar", "Base Unit"},
+	{"Harga Pokok", "Unit Cost"},
+	{"Nilai Stok (HPP)", "Stock Value (Cost)"},
+	{"Harga Jual", "Selling Price"},
+	{"Nilai Stok (Jual)", "Stock Value (Retail)"},
+}
+
+func exportInventoryValuation(c *gin.Context, format, lang string, valuation *models.InventoryValuation) {
+	name := "inventory-valuation-" + valuation.AsOf.Add(-time.Nanosecond).In(config.StoreLocation()).Format(utils.DateLayout)
+	streamExport(c, format, lang, name, inventoryValuationExportColumns, func(w utils.TableWriter) error {
+		for _, line := range valuation.Products {
+			if err := w.WriteRow(line.ProductID, line.Name, line.SKU, line.Category, utils.Quantity(line.Quantity), line.BaseUnit,
+				utils.Money(line.UnitCost), utils.Money(line.CostValue), utils.Money(line.UnitPrice), utils.Money(line.RetailValue)); err != nil {
+				return err
+			}
+		}
+		return w.WriteRow(nil, "TOTAL", nil, nil, nil, nil, nil, utils.Money(valuation.TotalCostValue), nil, utils.Money(valuation.TotalRetailValue))
+	})
+}
+
+// DeadStock - GET /report/dead-stock?days=90&max_sell_through=10
+func (h *ReportHandler) DeadStock(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 3650 {
+		utils.ValidationError(c, "Invalid days", "days must be a whole number between 1 and 3650")
+		return
+	}
+
+	// Without a threshold only products with no sales at all are listed
+	maxSellThrough, err := strconv.ParseFloat(c.DefaultQuery("max_sell_through", "0"), 64)
+	if err != nil || maxSellThrough < 0 || maxSellThrough > 100 {
+		utils.ValidationError(c, "Invalid max_sell_through", "max_sell_through must be a percentage between 0 and 100")
+		return
+	}
+
+	report, err := h.service.DeadStock(days, maxSellThrough)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to generate dead stock report", err.Error())
+		return
+	}
+
+	utils.Success(c, "Dead stock report generated successfully", report)
+}
+
+// BasketPairs - GET /report/basket-pairs?start_date=2024-01-01&end_date=2024-03-31&min_together=3
+func (h *ReportHandler) BasketPairs(c *gin.Context) {
+	start, end, ok := parseReportRange(c, 90)
+	if !ok {
+		return
+	}
+
+	minTogether, limit, ok := parseBasketParams(c, 50)
+	if !ok {
+		return
+	}
+
+	analysis, err := h.service.BasketPairs(start, end, minTogether, limit)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to analyse baskets", err.Error())
+		return
+	}
+
+	utils.Success(c, "Basket analysis generated successfully", analysis)
+}
+
+// AlsoBought - GET /products/{id}/also-bought
+func (h *ReportHandler) AlsoBought(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	start, end, ok := parseReportRange(c, 90)
+	if !ok {
+		return
+	}
+
+	minTogether, limit, ok := parseBasketParams(c, 10)
+	if !ok {
+		return
+	}
+
+	products, err := h.service.AlsoBought(id, start, end, minTogether, limit)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Product")
+			return
+		}
+		utils.InternalServerError(c, "Failed to fetch suggestions", err.Error())
+		return
+	}
+
+	utils.Success(c, "Suggestions retrieved successfully", products)
+}
+
+// parseBasketParams reads min_together (default 2) and limit (capped at 500)
+func parseBasketParams(c *gin.Context, defaultLimit int) (int, int, bool) {
+	minTogether, err := strconv.Atoi(c.DefaultQuery("min_together", "2"))
+	if err != nil || minTogether < 1 {
+		utils.ValidationError(c, "Invalid min_together", "min_together must be a positive whole number")
+		return 0, 0, false
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit < 1 {
+		utils.ValidationError(c, "Invalid limit", "limit must be a positive whole number")
+		return 0, 0, false
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	return minTogether, limit, true
+}
+
+// ABCAnalysis - GET /report/abc?start_date=2024-01-01&end_date=2024-03-31&category_id=3&threshold_a=80&threshold_b=95
+func (h *ReportHandler) ABCAnalysis(c *gin.Context) {
+	start, end, ok := parseReportRange(c, 90)
+	if !ok {
+		return
+	}
+
+	var categoryID *uint
+	if value := c.Query("category_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || id == 0 {
+			utils.ValidationError(c, "Invalid category_id", "category_id must be a positive whole number")
+			return
+		}
+		category := uint(id)
+		categoryID = &category
+	}
+
+	// Thresholds left out fall back to ABC_THRESHOLD_A and ABC_THRESHOLD_B
+	var thresholds [2]*float64
+	for i, name := range []string{"threshold_a", "threshold_b"} {
+		if value := c.Query(name); value != "" {
+			threshold, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				utils.ValidationError(c, "Invalid thresholds", "threshold_a and threshold_b must be percentages")
+				return
+			}
+			thresholds[i] = &threshold
+		}
+	}
+
+	analysis, err := h.service.ABCAnalysis(start, end, categoryID, thresholds[0], thresholds[1])
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidABCThresholds) {
+			utils.ValidationError(c, "Invalid thresholds", err.Error())
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Category")
+			return
+		}
+		utils.InternalServerError(c, "Failed to generate ABC analysis", err.Error())
+		return
+	}
+
+	utils.Success(c, "ABC analysis generated successfully", analysis)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"Kasir-API/models"
+	"Kasir-API/services"
+	"Kasir-API/utils"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ScaleBarcodeHandler struct {
+	service *services.ScaleBarcodeService
+}
+
+func NewScaleBarcodeHandler(service *services.ScaleBarcodeService) *ScaleBarcodeHandler {
+	return &ScaleBarcodeHandler{service: service}
+}
+
+// GetAll - GET /scale-barcode-rules
+func (h *ScaleBarcodeHandler) GetAll(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	rules, info, err := h.service.GetAll(page)
+	if err != nil {
+		respondListError(c, "Failed to fetch scale barcode rules", err)
+		return
+	}
+
+	utils.Paginated(c, "Scale barcode rules retrieved successfully", rules, page, info)
+}
+
+// Create - POST /scale-barcode-rules
+func (h *ScaleBarcodeHandler) Create(c *gin.Context) {
+	var request models.ScaleBarcodeRuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.ValidationError(c, "Validation error", err.Error())
+		return
+	}
+
+	rule, err := h.service.Create(request)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScaleRule) || errors.Is(err, services.ErrDuplicatePrefix) {
+			utils.BadRequest(c, err.Error(), nil)
+			return
+		}
+		utils.InternalServerError(c, "Failed to create scale barcode rule", err.Error())
+		return
+	}
+
+	utils.Created(c, "Scale barcode rule created successfully", rule)
+}
+
+// Update - PUT /scale-barcode-rules/{id}
+func (h *ScaleBarcodeHandler) Update(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "rule")
+	if !ok {
+		return
+	}
+
+	var request models.ScaleBarcodeRuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.ValidationError(c, "Validation error", err.Error())
+		return
+	}
+
+	rule, err := h.service.Update(id, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.NotFound(c, "Scale barcode rule")
+		case errors.Is(err, services.ErrInvalidScaleRule), errors.Is(err, services.ErrDuplicatePrefix):
+			utils.BadRequest(c, err.Error(), nil)
+		default:
+			utils.InternalServerError(c, "Failed to update scale barcode rule", err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, "Scale barcode rule updated successfully", rule)
+}
+
+// Delete - DELETE /scale-barcode-rules/{id}
+func (h *ScaleBarcodeHandler) Delete(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "rule")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.NotFound(c, "Scale barcode rule")
+			return
+		}
+		utils.InternalServerError(c, "Failed to delete scale barcode rule", err.Error())
+		return
+	}
+
+	utils.Success(c, "Scale barcode rule deleted successfully", gin.H{
+		"id": id,
+	})
+}
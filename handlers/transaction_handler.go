@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"Kasir-API/config"
 	"Kasir-API/models"
 	"Kasir-API/services"
 	"Kasir-API/utils"
-	"time"
+	"errors"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -34,29 +36,74 @@ func (h *TransactionHandler) Checkout(c *gin.Context) {
 }
 
 func (h *TransactionHandler) GetAll(c *gin.Context) {
-	transactions, err := h.service.GetAll()
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	transactions, info, err := h.service.GetAll(page)
 	if err != nil {
-		utils.InternalServerError(c, "Failed to retrieve transactions", err.Error())
+		respondListError(c, "Failed to retrieve transactions", err)
 		return
 	}
 
-	utils.Success(c, "Transactions retrieved successfully", transactions)
+	utils.Paginated(c, "Transactions retrieved successfully", transactions, page, info)
 }
 
 func (h *TransactionHandler) GetReport(c *gin.Context) {
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-
 	// Default to today if not provided
-	if startDate == "" {
-		startDate = time.Now().Format("2006-01-02")
+	start, end, ok := parseReportRange(c, 1)
+	if !ok {
+		return
+	}
+
+	// Roll the category breakdown up to this tree level (1 = top level, 0 = no roll-up)
+	categoryDepth, err := strconv.Atoi(c.DefaultQuery("category_depth", "0"))
+	if err != nil || categoryDepth < 0 {
+		utils.BadRequest(c, "Invalid category_depth", nil)
+		return
+	}
+
+	// Length of the best seller lists, capped at models.MaxReportTopN
+	topN, err := strconv.Atoi(c.DefaultQuery("top", strconv.Itoa(models.DefaultReportTopN)))
+	if err != nil || topN < 1 {
+		utils.BadRequest(c, "Invalid top", nil)
+		return
+	}
+
+	// Compare with the previous period, the same dates last year or a custom range
+	query := models.ReportQuery{
+		Start:         start,
+		End:           end,
+		CategoryDepth: categoryDepth,
+		TopN:          topN,
+		Compare:       c.Query("compare"),
+	}
+	switch query.Compare {
+	case models.CompareNone, models.ComparePrevious, models.CompareLastYear, models.CompareCustom:
+	default:
+		utils.ValidationError(c, "Invalid compare", services.ErrInvalidComparison.Error())
+		return
 	}
-	if endDate == "" {
-		endDate = time.Now().Format("2006-01-02")
+	if query.Compare == models.CompareCustom {
+		if c.Query("compare_start_date") == "" || c.Query("compare_end_date") == "" {
+			utils.ValidationError(c, "Invalid comparison range", "compare_start_date and compare_end_date are required for compare=custom")
+			return
+		}
+		compareRange, err := utils.ParseDateRange(c.Query("compare_start_date"), c.Query("compare_end_date"), config.StoreLocation(), 1)
+		if err != nil {
+			utils.ValidationError(c, "Invalid comparison range", err.Error())
+			return
+		}
+		query.CompareStart, query.CompareEnd = compareRange.Start, compareRange.End
 	}
 
-	report, err := h.service.GetReport(startDate, endDate)
+	report, err := h.service.GetReport(query)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidComparison) {
+			utils.ValidationError(c, "Invalid compare", err.Error())
+			return
+		}
 		utils.InternalServerError(c, "Failed to generate report", err.Error())
 		return
 	}
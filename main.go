@@ -9,6 +9,7 @@ import (
 	"Kasir-API/services"
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,6 +19,12 @@ import (
 func main() {
 	config.Init()
 
+	// Maintenance commands run instead of the server
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	// Log Railway environment info
 	log.Println("🚀 Starting Category API on Railway...")
 
@@ -45,16 +52,53 @@ func main() {
 	// Initialize database
 	database.ConnectDatabase()
 
+	// Initialize Category Dependencies
+	categoryRepo := repositories.NewCategoryRepository(database.GetDB())
+	categoryService := services.NewCategoryService(categoryRepo)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+
+	// Initialize Scale Barcode Dependencies
+	scaleBarcodeRepo := repositories.NewScaleBarcodeRepository(database.GetDB())
+	scaleBarcodeService := services.NewScaleBarcodeService(scaleBarcodeRepo)
+	scaleBarcodeHandler := handlers.NewScaleBarcodeHandler(scaleBarcodeService)
+
 	// Initialize Product Dependencies
 	productRepo := repositories.NewProductRepository(database.GetDB())
-	productService := services.NewProductService(productRepo)
+	productService := services.NewProductService(productRepo, scaleBarcodeService)
 	productHandler := handlers.NewProductHandler(productService)
 
+	// Apply scheduled price changes in the background
+	go productService.RunPriceScheduler(time.Minute)
+
 	// Initialize Transaction Dependencies
 	transactionRepo := repositories.NewTransactionRepository(database.GetDB())
-	transactionService := services.NewTransactionService(transactionRepo)
+	transactionService := services.NewTransactionService(transactionRepo, productService)
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
 
+	// Initialize Report Dependencies
+	reportRepo := repositories.NewReportRepository(database.GetDB())
+	reportService := services.NewReportService(reportRepo)
+	reportHandler := handlers.NewReportHandler(reportService)
+
+	// Fill the daily sales summaries once for installations that predate them, before any sale can be made
+	summaryService := services.NewSummaryService(repositories.NewSummaryRepository(database.GetDB()))
+	if err := summaryService.BackfillIfEmpty(); err != nil {
+		log.Fatal("Failed to backfill daily sales summaries:", err)
+	}
+
+	// Initialize Forecast Dependencies
+	purchaseListRepo := repositories.NewPurchaseListRepository(database.GetDB())
+	forecastService := services.NewForecastService(reportRepo, purchaseListRepo)
+	forecastHandler := handlers.NewForecastHandler(forecastService)
+
+	// Initialize Export Dependencies
+	exportService := services.NewExportService(repositories.NewExportRepository(database.GetDB()), transactionService)
+	exportHandler := handlers.NewExportHandler(exportService)
+
+	// Initialize Import Dependencies
+	importService := services.NewImportService(repositories.NewImportRepository(database.GetDB()))
+	importHandler := handlers.NewImportHandler(importService)
+
 	// Create router
 	router := gin.New()
 
@@ -101,27 +145,66 @@ func main() {
 	// Root route
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
-			"message": "GO-Kasir API is running",
-			"version": "1.0.0",
+			"message":    "GO-Kasir API is running",
+			"version":    "1.0.0",
+			"pagination": "List endpoints accept ?page=&limit= or ?cursor=&limit= (max 100 per page), cursors come from meta.next_cursor and meta.prev_cursor",
 			"endpoints": map[string]string{
-				"GET /":                       "API info",
-				"GET /health":                 "Basic health check",
-				"GET /health/db":              "Database health check",
-				"GET /metrics":                "Metrics endpoint",
-				"GET /categories":             "Get all categories",
-				"POST /categories":            "Create new category",
-				"GET /categories/:id":         "Get category by ID",
-				"PUT /categories/:id":         "Update category",
-				"DELETE /categories/:id":      "Delete category",
-				"GET /products":               "Get all products",
-				"POST /products":              "Create new product",
-				"GET /products/:id":           "Get product by ID",
-				"PUT /products/:id":           "Update product",
-				"DELETE /products/:id":        "Delete product",
-				"GET /transactions":           "Get all transactions",
-				"POST /transactions/checkout": "Process checkout",
-				"GET /report/hari-ini":        "Get today's sales report",
-				"GET /report":                 "Get sales report with date filter",
+				"GET /":                                 "API info",
+				"GET /health":                           "Basic health check",
+				"GET /health/db":                        "Database health check",
+				"GET /metrics":                          "Metrics endpoint",
+				"GET /categories":                       "Get all categories",
+				"POST /categories":                      "Create new category",
+				"GET /categories/tree":                  "Get nested category tree",
+				"GET /categories/:id":                   "Get category by ID",
+				"PUT /categories/:id":                   "Update category",
+				"DELETE /categories/:id":                "Archive category (?permanent=true, ?target_category_id= to move products)",
+				"POST /categories/:id/restore":          "Restore archived category",
+				"GET /products":                         "Get all products",
+				"POST /products":                        "Create new product",
+				"GET /products/:id":                     "Get product by ID",
+				"GET /products/barcode/:code":           "Get product by barcode, SKU or scale label",
+				"PUT /products/:id":                     "Update product",
+				"DELETE /products/:id":                  "Archive product (?permanent=true to delete)",
+				"POST /products/:id/restore":            "Restore archived product",
+				"POST /products/:id/receive":            "Receive stock and update average cost",
+				"POST /products/labels":                 "Generate shelf labels (PDF or SVG)",
+				"POST /products/import":                 "Create or update products from CSV or XLSX (?dry_run=true)",
+				"GET /products/:id/prices":              "Get product price history",
+				"POST /products/:id/prices":             "Change or schedule product price",
+				"DELETE /products/:id/prices/:price_id": "Cancel scheduled price",
+				"GET /products/:id/units":               "Get product units",
+				"POST /products/:id/units":              "Create product unit",
+				"PUT /products/:id/units/:unit_id":      "Update product unit",
+				"DELETE /products/:id/units/:unit_id":   "Delete product unit",
+				"GET /products/:id/also-bought":         "Get products customers also bought",
+				"GET /scale-barcode-rules":              "Get scale barcode rules",
+				"POST /scale-barcode-rules":             "Create scale barcode rule",
+				"PUT /scale-barcode-rules/:id":          "Update scale barcode rule",
+				"DELETE /scale-barcode-rules/:id":       "Delete scale barcode rule",
+				"GET /transactions":                     "Get all transactions",
+				"POST /transactions/checkout":           "Process checkout",
+				"GET /report/hari-ini":                  "Get today's sales report",
+				"GET /report":                           "Get sales report with date filter (?top= best sellers, ?category_depth=, ?compare=previous|last_year|custom)",
+				"GET /report/timeseries":                "Get revenue, transactions and items sold per hour, day, week or month",
+				"GET /report/heatmap":                   "Get sales by hour of day and day of week",
+				"GET /report/x":                         "Get X-report since the last closing (?format=text)",
+				"POST /report/z":                        "Close the day and store a Z-report (?format=text)",
+				"GET /report/z":                         "Get stored Z-reports",
+				"GET /report/z/:id":                     "Get Z-report by ID (?format=text)",
+				"GET /report/inventory-valuation":       "Get stock value at cost and retail (?as_of=YYYY-MM-DD, ?format=csv|xlsx, ?lang=id|en)",
+				"GET /report/dead-stock":                "Get products without sales or with low sell-through (?days=, ?max_sell_through=)",
+				"GET /report/basket-pairs":              "Get products bought together with support, confidence and lift",
+				"GET /report/forecast":                  "Get demand forecast and suggested reorder quantities",
+				"GET /report/abc":                       "Get ABC classification of products by revenue (?category_id=, ?threshold_a=, ?threshold_b=)",
+				"GET /purchase-lists":                   "Get purchase lists",
+				"POST /purchase-lists/from-forecast":    "Create a draft purchase list from reorder suggestions",
+				"GET /purchase-lists/:id":               "Get purchase list by ID",
+				"DELETE /purchase-lists/:id":            "Delete purchase list",
+				"GET /export/transactions":              "Export transaction lines (?format=csv|xlsx, ?lang=id|en)",
+				"GET /export/report":                    "Export the sales report per product or category (?group_by=)",
+				"GET /export/products":                  "Export products",
+				"GET /export/stock":                     "Export stock levels and value",
 			},
 		})
 	})
@@ -129,20 +212,43 @@ func main() {
 	// Category routes
 	categoryRoutes := router.Group("/categories")
 	{
-		categoryRoutes.GET("/", handlers.GetAllCategories)
-		categoryRoutes.POST("/", handlers.CreateCategory)
-		categoryRoutes.GET("/:id", handlers.GetCategoryByID)
-		categoryRoutes.PUT("/:id", handlers.UpdateCategory)
-		categoryRoutes.DELETE("/:id", handlers.DeleteCategory)
+		categoryRoutes.GET("/", categoryHandler.GetAll)
+		categoryRoutes.POST("/", categoryHandler.Create)
+		categoryRoutes.GET("/tree", categoryHandler.GetTree)
+		categoryRoutes.GET("/:id", categoryHandler.GetByID)
+		categoryRoutes.PUT("/:id", categoryHandler.Update)
+		categoryRoutes.DELETE("/:id", categoryHandler.Delete)
+		categoryRoutes.POST("/:id/restore", categoryHandler.Restore)
 	}
 
 	productRoutes := router.Group("/products")
 	{
 		productRoutes.GET("/", productHandler.GetAll)
 		productRoutes.POST("/", handlers.CreateProduct)
+		productRoutes.POST("/labels", productHandler.GenerateLabels)
+		productRoutes.POST("/import", importHandler.ImportProducts)
 		productRoutes.GET("/:id", handlers.GetProductByID)
+		productRoutes.GET("/barcode/:code", productHandler.GetByBarcode)
 		productRoutes.PUT("/:id", handlers.UpdateProduct)
 		productRoutes.DELETE("/:id", handlers.DeleteProduct)
+		productRoutes.POST("/:id/restore", handlers.RestoreProduct)
+		productRoutes.POST("/:id/receive", productHandler.ReceiveStock)
+		productRoutes.GET("/:id/prices", productHandler.GetPrices)
+		productRoutes.POST("/:id/prices", productHandler.SchedulePrice)
+		productRoutes.DELETE("/:id/prices/:price_id", productHandler.CancelPrice)
+		productRoutes.GET("/:id/units", productHandler.GetUnits)
+		productRoutes.POST("/:id/units", productHandler.CreateUnit)
+		productRoutes.PUT("/:id/units/:unit_id", productHandler.UpdateUnit)
+		productRoutes.DELETE("/:id/units/:unit_id", productHandler.DeleteUnit)
+		productRoutes.GET("/:id/also-bought", reportHandler.AlsoBought)
+	}
+
+	scaleBarcodeRoutes := router.Group("/scale-barcode-rules")
+	{
+		scaleBarcodeRoutes.GET("/", scaleBarcodeHandler.GetAll)
+		scaleBarcodeRoutes.POST("/", scaleBarcodeHandler.Create)
+		scaleBarcodeRoutes.PUT("/:id", scaleBarcodeHandler.Update)
+		scaleBarcodeRoutes.DELETE("/:id", scaleBarcodeHandler.Delete)
 	}
 
 	transactionRoutes := router.Group("/transactions")
@@ -155,6 +261,33 @@ func main() {
 	{
 		reportRoutes.GET("/hari-ini", transactionHandler.GetReport)
 		reportRoutes.GET("/", transactionHandler.GetReport)
+		reportRoutes.GET("/timeseries", reportHandler.TimeSeries)
+		reportRoutes.GET("/heatmap", reportHandler.Heatmap)
+		reportRoutes.GET("/x", reportHandler.XReport)
+		reportRoutes.POST("/z", reportHandler.CloseDay)
+		reportRoutes.GET("/z", reportHandler.GetZReports)
+		reportRoutes.GET("/z/:id", reportHandler.GetZReport)
+		reportRoutes.GET("/inventory-valuation", reportHandler.InventoryValuation)
+		reportRoutes.GET("/dead-stock", reportHandler.DeadStock)
+		reportRoutes.GET("/basket-pairs", reportHandler.BasketPairs)
+		reportRoutes.GET("/forecast", forecastHandler.Forecast)
+		reportRoutes.GET("/abc", reportHandler.ABCAnalysis)
+	}
+
+	purchaseListRoutes := router.Group("/purchase-lists")
+	{
+		purchaseListRoutes.GET("/", forecastHandler.GetPurchaseLists)
+		purchaseListRoutes.POST("/from-forecast", forecastHandler.CreatePurchaseList)
+		purchaseListRoutes.GET("/:id", forecastHandler.GetPurchaseList)
+		purchaseListRoutes.DELETE("/:id", forecastHandler.DeletePurchaseList)
+	}
+
+	exportRoutes := router.Group("/export")
+	{
+		exportRoutes.GET("/transactions", exportHandler.ExportTransactions)
+		exportRoutes.GET("/report", exportHandler.ExportReport)
+		exportRoutes.GET("/products", exportHandler.ExportProducts)
+		exportRoutes.GET("/stock", exportHandler.ExportStock)
 	}
 
 	// Start server
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	ABCClassA = "A"
+	ABCClassB = "B"
+	ABCClassC = "C"
+)
+
+// ABCProduct is one product ranked by its share of the revenue
+type ABCProduct struct {
+	Rank          int     `json:"rank"`
+	ProductID     uint    `json:"product_id"`
+	Name          string  `json:"nama"`
+	SKU           *string `json:"sku,omitempty"`
+	CategoryID    uint    `json:"category_id"`
+	Category      string  `json:"category"` // Full path, e.g. "Minuman > Kopi"
+	QtySold       float64 `json:"qty_terjual"`
+	Revenue       int     `json:"revenue"`
+	RevenuePct    float64 `json:"revenue_pct"`    // Share of the total revenue
+	CumulativePct float64 `json:"cumulative_pct"` // Share of this and all higher ranked products
+	Class         string  `json:"class"`
+	Stock         float64 `json:"stock"` // In base unit
+	BaseUnit      string  `json:"base_unit"`
+}
+
+// ABCClassSummary counts the products and revenue of one class
+type ABCClassSummary struct {
+	Class       string  `json:"class"`
+	Products    int     `json:"products"`
+	ProductsPct float64 `json:"products_pct"`
+	Revenue     int     `json:"revenue"`
+	RevenuePct  float64 `json:"revenue_pct"`
+}
+
+// ABCAnalysis classifies products by revenue contribution: A until the cumulative share reaches
+// ThresholdA, B until ThresholdB and C for the rest, including products that did not sell
+type ABCAnalysis struct {
+	Start        time.Time         `json:"start"`
+	End          time.Time         `json:"end"`
+	CategoryID   *uint             `json:"category_id,omitempty"` // Includes subcategories
+	ThresholdA   float64           `json:"threshold_a"`
+	ThresholdB   float64           `json:"threshold_b"`
+	TotalRevenue int               `json:"total_revenue"`
+	Classes      []ABCClassSummary `json:"classes"`
+	Products     []ABCProduct      `json:"products"`
+}
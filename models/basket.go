@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ProductPair is a pair of products bought in the same transaction.
+// Support is the share of all baskets containing both, confidence the share of baskets with
+// the first product that also contain the second, and lift how much more often they appear
+// together than if they were bought independently (> 1 means they go together).
+type ProductPair struct {
+	ProductA    uint    `json:"product_a_id"`
+	NameA       string  `json:"product_a_nama"`
+	ProductB    uint    `json:"product_b_id"`
+	NameB       string  `json:"product_b_nama"`
+	Together    int     `json:"together"` // Baskets with both products
+	CountA      int     `json:"-"`
+	CountB      int     `json:"-"`
+	Support     float64 `json:"support"`
+	ConfidenceA float64 `json:"confidence_a_to_b"`
+	ConfidenceB float64 `json:"confidence_b_to_a"`
+	Lift        float64 `json:"lift"`
+}
+
+type BasketAnalysis struct {
+	Start       time.Time     `json:"start"`
+	End         time.Time     `json:"end"`
+	Baskets     int           `json:"baskets"`
+	MinTogether int           `json:"min_together"`
+	Pairs       []ProductPair `json:"pairs"`
+}
+
+// AlsoBoughtProduct is a suggestion for a product, ranked by confidence
+type AlsoBoughtProduct struct {
+	ProductID  uint    `json:"product_id"`
+	Name       string  `json:"nama"`
+	Price      float64 `json:"price"`
+	Together   int     `json:"together"`
+	Count      int     `json:"-"`
+	Confidence float64 `json:"confidence"`
+	Lift       float64 `json:"lift"`
+}
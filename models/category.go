@@ -1,15 +1,29 @@
 package models
 
 import (
+	"gorm.io/gorm"
 	"time"
 )
 
 type Category struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"size:100;not null;uniqueIndex"` // Added uniqueIndex for faster lookups
-	Description string    `json:"description" gorm:"type:text"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"size:100;not null;uniqueIndex"` // Added uniqueIndex for faster lookups
+	Description string         `json:"description" gorm:"type:text"`
+	ParentID    *uint          `json:"parent_id" gorm:"index"`
+	Parent      *Category      `json:"-" gorm:"foreignKey:ParentID"`
+	Children    []*Category    `json:"children,omitempty" gorm:"-"` // Only filled by the tree endpoint
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"archived_at" gorm:"index"` // Archived categories are hidden from lists
+}
+
+// CategoryDeleteResult reports what happened to a deleted category's products and subcategories
+type CategoryDeleteResult struct {
+	ID               uint  `json:"id"`
+	Permanent        bool  `json:"permanent"`
+	TargetCategoryID *uint `json:"target_category_id,omitempty"`
+	ProductsMoved    int64 `json:"products_moved"`
+	CategoriesMoved  int64 `json:"categories_moved"`
 }
 
 // OPTIONAL
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// TransactionExportLine is one transaction line with its header fields, as streamed to exports
+type TransactionExportLine struct {
+	TransactionID uint
+	CreatedAt     time.Time
+	TotalAmount   int
+	LineID        uint
+	ProductID     uint
+	ProductName   string
+	SKU           *string
+	Quantity      float64 // In product base unit
+	BaseUnit      string
+	Unit          string
+	UnitQuantity  float64
+	Subtotal      int
+	CostSubtotal  int
+}
+
+// ProductExportRow is a product with its stock position, as streamed to exports
+type ProductExportRow struct {
+	ID          uint
+	Name        string
+	SKU         *string
+	PLU         *string
+	Barcodes    string // Comma separated
+	CategoryID  uint
+	Category    string `gorm:"-"` // Full path, filled from CategoryID
+	MeasureType string
+	BaseUnit    string
+	Price       float64
+	CostPrice   float64
+	Stock       float64
+}
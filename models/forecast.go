@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// ProductForecast is the expected demand of a product and how much to reorder to cover it
+type ProductForecast struct {
+	ProductID         uint       `json:"product_id"`
+	Name              string     `json:"nama"`
+	BaseUnit          string     `json:"base_unit"`
+	Stock             float64    `json:"stock"`
+	AvgDailyDemand    float64    `json:"avg_daily_demand"` // Smoothed level, before weekday adjustment
+	WeekdayIndex      [7]float64 `json:"weekday_index"`    // Sunday first, 1 = an average day
+	DemandLeadTime    float64    `json:"demand_lead_time"` // Expected sales until a new order arrives
+	DemandHorizon     float64    `json:"demand_horizon"`   // Expected sales over lead time plus cover days
+	SafetyStock       float64    `json:"safety_stock"`
+	ReorderPoint      float64    `json:"reorder_point"`
+	SuggestedQuantity float64    `json:"suggested_quantity"` // 0 when stock is above the reorder point
+	UnitCost          float64    `json:"unit_cost"`
+}
+
+type ForecastReport struct {
+	GeneratedAt  time.Time         `json:"generated_at"`
+	HistoryDays  int               `json:"history_days"`
+	LeadTimeDays int               `json:"lead_time_days"`
+	CoverDays    int               `json:"cover_days"`
+	Products     []ProductForecast `json:"products"`
+}
+
+type ForecastRequest struct {
+	HistoryDays  int    `json:"history_days" form:"history_days" binding:"omitempty,min=7,max=365"`
+	LeadTimeDays *int   `json:"lead_time_days" form:"lead_time_days" binding:"omitempty,min=0,max=180"`
+	CoverDays    *int   `json:"cover_days" form:"cover_days" binding:"omitempty,min=1,max=180"`
+	ProductIDs   []uint `json:"product_ids" form:"product_id"`
+}
+
+const PurchaseListDraft = "draft"
+
+// PurchaseList is a list of products to order from suppliers
+type PurchaseList struct {
+	ID        uint               `json:"id" gorm:"primaryKey"`
+	Status    string             `json:"status" gorm:"size:20;not null;default:'draft';index"`
+	Note      string             `json:"note" gorm:"type:text"`
+	TotalCost float64            `json:"total_cost" gorm:"not null;default:0"`
+	Items     []PurchaseListItem `json:"items,omitempty" gorm:"foreignKey:PurchaseListID"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+type PurchaseListItem struct {
+	ID             uint    `json:"id" gorm:"primaryKey"`
+	PurchaseListID uint    `json:"purchase_list_id" gorm:"not null;index"`
+	ProductID      uint    `json:"product_id" gorm:"not null;index"`
+	ProductName    string  `json:"product_name" gorm:"size:100"`
+	Quantity       float64 `json:"quantity" gorm:"type:numeric(14,3);not null"` // In base unit
+	BaseUnit       string  `json:"base_unit" gorm:"size:20"`
+	UnitCost       float64 `json:"unit_cost" gorm:"not null;default:0"`
+	Subtotal       float64 `json:"subtotal" gorm:"not null;default:0"`
+	StockAtCreate  float64 `json:"stock_at_create" gorm:"type:numeric(14,3)"`
+	ForecastDemand float64 `json:"forecast_demand" gorm:"type:numeric(14,3)"`
+}
+
+type PurchaseListFromForecastRequest struct {
+	ForecastRequest
+	Note string `json:"note" binding:"max=500"`
+}
@@ -0,0 +1,50 @@
+package models
+
+const (
+	MaxImportRows = 5000
+
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+)
+
+// ProductImportFields are the fields an import column can be mapped to
+var ProductImportFields = []string{"name", "sku", "plu", "barcodes", "category", "price", "cost_price", "stock", "base_unit", "measure_type", "quantity_precision"}
+
+// ProductImportRow is one parsed row of a product import. Nil and empty fields were empty
+// in the file and keep the current value of an existing product.
+type ProductImportRow struct {
+	Row               int // Line in the file, the header is line 1
+	Name              string
+	SKU               string
+	PLU               *string
+	Barcodes          *[]string
+	Category          string // Path such as "Minuman > Kopi", missing categories are created
+	Price             *float64
+	CostPrice         *float64
+	Stock             *float64
+	BaseUnit          string
+	MeasureType       string
+	QuantityPrecision *int
+	Errors            []string
+}
+
+type ProductImportRowResult struct {
+	Row       int      `json:"row"`
+	Name      string   `json:"name"`
+	SKU       string   `json:"sku,omitempty"`
+	Action    string   `json:"action,omitempty"` // create or update
+	ProductID uint     `json:"product_id,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+type ProductImportResult struct {
+	DryRun            bool                     `json:"dry_run"`
+	Applied           bool                     `json:"applied"` // False when any row has errors or on a dry run
+	Columns           map[string]string        `json:"columns"` // Field to file column
+	TotalRows         int                      `json:"total_rows"`
+	Created           int                      `json:"created"`
+	Updated           int                      `json:"updated"`
+	InvalidRows       int                      `json:"invalid_rows"`
+	CategoriesCreated []string                 `json:"categories_created"`
+	Rows              []ProductImportRowResult `json:"rows"`
+}
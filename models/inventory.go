@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// InventoryValuationLine is one product's stock on hand valued at cost and at retail
+type InventoryValuationLine struct {
+	ProductID   uint    `json:"product_id"`
+	Name        string  `json:"nama"`
+	SKU         *string `json:"sku,omitempty"`
+	CategoryID  uint    `json:"category_id"`
+	Category    string  `json:"category"` // Full path, e.g. "Minuman > Kopi"
+	Quantity    float64 `json:"quantity"` // In base unit
+	BaseUnit    string  `json:"base_unit"`
+	UnitCost    float64 `json:"unit_cost"`
+	CostValue   float64 `json:"cost_value"`
+	UnitPrice   float64 `json:"unit_price"`
+	RetailValue float64 `json:"retail_value"`
+}
+
+// CategoryValuation subtotals the lines of one category
+type CategoryValuation struct {
+	CategoryID  uint    `json:"category_id"`
+	Path        string  `json:"path"`
+	Products    int     `json:"products"`
+	CostValue   float64 `json:"cost_value"`
+	RetailValue float64 `json:"retail_value"`
+}
+
+type InventoryValuation struct {
+	AsOf             time.Time                `json:"as_of"`
+	Reconstructed    bool                     `json:"reconstructed"` // True when rebuilt from stock and sales history
+	TotalCostValue   float64                  `json:"total_cost_value"`
+	TotalRetailValue float64                  `json:"total_retail_value"`
+	Categories       []CategoryValuation      `json:"categories"`
+	Products         []InventoryValuationLine `json:"products"`
+}
+
+const (
+	StockStatusDead = "dead" // No sales in the window
+	StockStatusSlow = "slow" // Sell-through below the threshold
+)
+
+// SlowMovingProduct is a product with stock on hand that sold little or nothing in the window
+type SlowMovingProduct struct {
+	ProductID   uint       `json:"product_id"`
+	Name        string     `json:"nama"`
+	SKU         *string    `json:"sku,omitempty"`
+	CategoryID  uint       `json:"category_id"`
+	Status      string     `json:"status"`
+	Stock       float64    `json:"stock"`
+	BaseUnit    string     `json:"base_unit"`
+	StockValue  float64    `json:"stock_value"` // Stock at cost
+	QtySold     float64    `json:"qty_terjual"` // Within the window
+	SellThrough float64    `json:"sell_through_pct"`
+	DaysOfCover *float64   `json:"days_of_cover"` // Null when nothing sold, stock never runs out at this rate
+	LastSoldAt  *time.Time `json:"last_sold_at"`
+	CostPrice   float64    `json:"-"`
+	CreatedAt   time.Time  `json:"-"`
+}
+
+type DeadStockReport struct {
+	Days            int                 `json:"days"`
+	Since           time.Time           `json:"since"`
+	MaxSellThrough  float64             `json:"max_sell_through_pct"`
+	TotalStockValue float64             `json:"total_stock_value"`
+	Products        []SlowMovingProduct `json:"products"`
+}
@@ -0,0 +1,19 @@
+package models
+
+const (
+	LabelFormatPDF = "pdf"
+	LabelFormatSVG = "svg"
+
+	SymbologyAuto    = "auto"
+	SymbologyCode128 = "code128"
+	SymbologyEAN13   = "ean13"
+)
+
+type LabelRequest struct {
+	ProductIDs   []uint `json:"product_ids" binding:"omitempty,dive,gt=0"`
+	ChangedSince string `json:"changed_since"` // YYYY-MM-DD, selects products whose price changed since this date
+	Format       string `json:"format" binding:"omitempty,oneof=pdf svg"`
+	Symbology    string `json:"symbology" binding:"omitempty,oneof=auto code128 ean13"`
+	Columns      int    `json:"columns" binding:"omitempty,min=1,max=6"`
+	Rows         int    `json:"rows" binding:"omitempty,min=1,max=12"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// Page is the window of a list query, resolved from page/limit or a cursor
+type Page struct {
+	Limit  int
+	Offset int
+	After  uint // ID of the last row the client has seen, set when paging forward with a cursor
+	Before uint // ID of the first row the client has seen, set when paging backward with a cursor
+}
+
+// PageInfo is what a list query learned about the rows around the page it loaded
+type PageInfo struct {
+	Total   int64
+	HasMore bool // More rows follow the page in the direction it was read, backward for Before
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductPrice is one entry in a product's price history. Entries with a future EffectiveFrom
+// are scheduled and copied to products.price once they become effective.
+type ProductPrice struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ProductID     uint      `json:"product_id" gorm:"not null;index:idx_product_prices_effective,priority:1"`
+	Price         float64   `json:"price" gorm:"not null"`
+	EffectiveFrom time.Time `json:"effective_from" gorm:"not null;index:idx_product_prices_effective,priority:2"`
+	Applied       bool      `json:"applied" gorm:"not null;default:false;index"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ProductPriceRequest struct {
+	Price         float64    `json:"price" binding:"required,gt=0"`
+	EffectiveFrom *time.Time `json:"effective_from"` // RFC3339, defaults to now
+}
@@ -2,18 +2,67 @@ package models
 
 import (
 	"gorm.io/gorm"
+	"math"
 	"time"
 )
 
+const (
+	MeasureCount   = "count"   // Sold per piece
+	MeasureWeight  = "weight"  // Sold by weight, e.g. kg
+	MeasureMeasure = "measure" // Sold by length or volume, e.g. m or l
+
+	MaxQuantityPrecision = 3
+)
+
 type Product struct {
-	ID         uint      `json:"id" gorm:"primaryKey"`
-	Name       string    `json:"name" gorm:"size:100;not null"`
-	Price      float64   `json:"price" gorm:"not null"`
-	Stock      int       `json:"stock" gorm:"not null"`
-	CategoryID uint      `json:"-" gorm:"not null;index"`
-	Category   *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID                uint             `json:"id" gorm:"primaryKey"`
+	Name              string           `json:"name" gorm:"size:100;not null"`
+	SKU               *string          `json:"sku,omitempty" gorm:"size:64;uniqueIndex"`
+	PLU               *string          `json:"plu,omitempty" gorm:"size:6;uniqueIndex"` // Scale PLU without leading zeros
+	Price             float64          `json:"price" gorm:"not null;index"`
+	PriceChangedAt    *time.Time       `json:"price_changed_at,omitempty" gorm:"index"`
+	CostPrice         float64          `json:"cost_price" gorm:"not null;default:0"`           // Weighted moving average cost per unit
+	Stock             float64          `json:"stock" gorm:"type:numeric(14,3);not null;index"` // Always in base unit
+	MeasureType       string           `json:"measure_type" gorm:"size:10;not null;default:'count'"`
+	QuantityPrecision int              `json:"quantity_precision" gorm:"not null;default:0"` // Allowed decimal places for quantities
+	BaseUnit          string           `json:"base_unit" gorm:"size:20;not null;default:'pcs'"`
+	CategoryID        uint             `json:"-" gorm:"not null;index"`
+	Category          *Category        `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Barcodes          []ProductBarcode `json:"barcodes,omitempty" gorm:"foreignKey:ProductID"`
+	Units             []ProductUnit    `json:"units,omitempty" gorm:"foreignKey:ProductID"`
+	Prices            []ProductPrice   `json:"-" gorm:"foreignKey:ProductID"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at" gorm:"index"`
+	DeletedAt         gorm.DeletedAt   `json:"archived_at" gorm:"index"` // Archived products stay resolvable in history
+}
+
+// UnitConversion returns how many base units one unit contains and its selling price.
+// An empty name or the base unit converts 1:1 at the product price. Units must be preloaded.
+func (p *Product) UnitConversion(unit string) (factor float64, price float64, ok bool) {
+	if unit == "" || unit == p.BaseUnit {
+		return 1, p.Price, true
+	}
+
+	for _, u := range p.Units {
+		if u.Name == unit {
+			price = u.Price
+			if price == 0 {
+				price = p.Price * u.ConversionFactor
+			}
+			return u.ConversionFactor, price, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// ValidQuantity reports whether quantity is positive and within the product's decimal precision
+func (p *Product) ValidQuantity(quantity float64) bool {
+	if quantity <= 0 {
+		return false
+	}
+	scale := math.Pow(10, float64(p.QuantityPrecision))
+	return math.Abs(quantity*scale-math.Round(quantity*scale)) < 1e-6
 }
 
 func (p *Product) AfterFind(tx *gorm.DB) (err error) {
@@ -22,3 +71,42 @@ func (p *Product) AfterFind(tx *gorm.DB) (err error) {
 	}
 	return
 }
+
+type ProductBarcode struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ProductID uint      `json:"product_id" gorm:"not null;index"`
+	Code      string    `json:"code" gorm:"size:64;not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProductFilter holds the product list query parameters
+type ProductFilter struct {
+	Name              string   `form:"name"`
+	Query             string   `form:"q"`           // Typo-tolerant search on name and SKU
+	CategoryID        uint     `form:"category_id"` // Includes products in all descendant categories
+	MinPrice          *float64 `form:"min_price" binding:"omitempty,gte=0"`
+	MaxPrice          *float64 `form:"max_price" binding:"omitempty,gte=0"`
+	InStock           bool     `form:"in_stock"`
+	LowStock          bool     `form:"low_stock"`
+	LowStockThreshold *float64 `form:"low_stock_threshold" binding:"omitempty,gte=0"`
+	Archived          bool     `form:"archived"`
+	Sort              string   `form:"sort" binding:"omitempty,oneof=name price stock updated_at relevance"`
+	Order             string   `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+// ProductUnit is an alternative unit a product can be sold or received in, e.g. a pack of 20 sticks
+type ProductUnit struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	ProductID        uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_product_unit_name"`
+	Name             string    `json:"name" gorm:"size:20;not null;uniqueIndex:idx_product_unit_name"`
+	ConversionFactor float64   `json:"conversion_factor" gorm:"type:numeric(14,3);not null"` // Base units per one of this unit
+	Price            float64   `json:"price" gorm:"not null;default:0"`                      // 0 means price * conversion_factor
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type ProductUnitRequest struct {
+	Name             string  `json:"name" binding:"required,max=20"`
+	ConversionFactor float64 `json:"conversion_factor" binding:"required,gt=0"`
+	Price            float64 `json:"price" binding:"gte=0"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	RegisterReportX = "X" // Read-only snapshot since the last Z-report
+	RegisterReportZ = "Z" // Closes the business day
+)
+
+// RegisterTotals are the cash register figures of a period. The POS has no discounts or
+// refunds yet, so those stay 0 and net sales equal gross sales. Prices include tax.
+type RegisterTotals struct {
+	PeriodStart        time.Time  `json:"period_start"`
+	PeriodEnd          time.Time  `json:"period_end"`
+	GrossSales         int        `json:"gross_sales" gorm:"not null"`
+	Discounts          int        `json:"discounts" gorm:"not null"`
+	Refunds            int        `json:"refunds" gorm:"not null"`
+	NetSales           int        `json:"net_sales" gorm:"not null"`
+	TaxRate            float64    `json:"tax_rate" gorm:"not null"` // Percent
+	Tax                int        `json:"tax" gorm:"not null"`
+	TransactionCount   int        `json:"transaction_count" gorm:"not null"`
+	FirstTransactionID *uint      `json:"first_transaction_id"`
+	FirstTransactionAt *time.Time `json:"first_transaction_at"`
+	LastTransactionID  *uint      `json:"last_transaction_id"`
+	LastTransactionAt  *time.Time `json:"last_transaction_at"`
+	ItemsSold          float64    `json:"items_sold" gorm:"type:numeric(14,3);not null"` // Sum of base-unit quantities
+}
+
+// XReport is computed on request and never stored
+type XReport struct {
+	Type        string    `json:"type"`
+	GeneratedAt time.Time `json:"generated_at"`
+	RegisterTotals
+}
+
+// ZReport is the stored, immutable closing record of a business day
+type ZReport struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	Number         uint   `json:"number" gorm:"not null;uniqueIndex"` // Running Z counter
+	BusinessDate   string `json:"business_date" gorm:"size:10;not null;index"`
+	RegisterTotals `gorm:"embedded"`
+	// Every transaction up to this ID is covered by this or an earlier Z-report
+	ThroughTransactionID uint      `json:"through_transaction_id" gorm:"not null;default:0"`
+	CreatedAt            time.Time `json:"created_at"`
+}
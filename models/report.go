@@ -1,12 +1,159 @@
 package models
 
+import "time"
+
+const (
+	DefaultReportTopN = 5
+	MaxReportTopN     = 50
+)
+
+// ReportQuery holds the options of a sales report
+type ReportQuery struct {
+	Start         time.Time // Inclusive
+	End           time.Time // Exclusive
+	CategoryDepth int       // Roll categories up to this tree level, 0 = no roll-up
+	TopN          int       // Length of the best seller lists
+	FromSummaries bool      // Read the daily summary tables, only valid for whole store-local days
+	Compare       string    // Comparison period, empty for none
+	CompareStart  time.Time // Range of a custom comparison period
+	CompareEnd    time.Time
+}
+
+const (
+	CompareNone     = ""
+	ComparePrevious = "previous"  // The same length of time right before the report range
+	CompareLastYear = "last_year" // The same dates one year earlier
+	CompareCustom   = "custom"
+)
+
+const (
+	ReportSourceLive    = "live"
+	ReportSourceSummary = "summary"
+)
+
 type BestSellingProduct struct {
-	Name    string `json:"nama"`
-	QtySold int    `json:"qty_terjual"`
+	ProductID uint    `json:"product_id,omitempty"`
+	Name      string  `json:"nama"`
+	QtySold   float64 `json:"qty_terjual"`
+}
+
+// TopSeller is one entry of a ranked best seller list
+type TopSeller struct {
+	Rank      int     `json:"rank"`
+	ProductID uint    `json:"product_id"`
+	Name      string  `json:"nama"`
+	QtySold   float64 `json:"qty_terjual"`
+	Revenue   int     `json:"revenue"`
+}
+
+type ProductProfit struct {
+	ProductID   uint    `json:"product_id"`
+	Name        string  `json:"nama"`
+	QtySold     float64 `json:"qty_terjual"`
+	Revenue     int     `json:"revenue"`
+	COGS        int     `json:"cogs"`
+	GrossProfit int     `json:"gross_profit"`
+	MarginPct   float64 `json:"gross_margin_pct"`
+}
+
+type CategoryProfit struct {
+	CategoryID  uint    `json:"category_id"`
+	Name        string  `json:"nama"`
+	Path        string  `json:"path"` // e.g. "Minuman > Kopi > Kopi Susu"
+	QtySold     float64 `json:"qty_terjual"`
+	Revenue     int     `json:"revenue"`
+	COGS        int     `json:"cogs"`
+	GrossProfit int     `json:"gross_profit"`
+	MarginPct   float64 `json:"gross_margin_pct"`
 }
 
 type ReportResponse struct {
+	Start          time.Time          `json:"start"`  // Inclusive, in the store timezone
+	End            time.Time          `json:"end"`    // Exclusive
+	Source         string             `json:"source"` // live or summary
 	TotalRevenue   int                `json:"total_revenue"`
 	TotalTransaksi int                `json:"total_transaksi"`
 	ProdukTerlaris BestSellingProduct `json:"produk_terlaris"`
+	AverageBasket  float64            `json:"average_basket"` // Revenue per transaction
+	TopByQuantity  []TopSeller        `json:"top_by_quantity"`
+	TopByRevenue   []TopSeller        `json:"top_by_revenue"`
+	TotalCOGS      int                `json:"total_cogs"`
+	GrossProfit    int                `json:"gross_profit"`
+	GrossMarginPct float64            `json:"gross_margin_pct"`
+	PerProduct     []ProductProfit    `json:"per_product"`
+	PerCategory    []CategoryProfit   `json:"per_category"`
+	Comparison     *ReportComparison  `json:"comparison,omitempty"`
+}
+
+// MetricDelta compares a figure of the report range with the comparison period
+type MetricDelta struct {
+	Current   float64  `json:"current"`
+	Previous  float64  `json:"previous"`
+	Change    float64  `json:"change"`
+	ChangePct *float64 `json:"change_pct"` // Nil when the previous value is 0
+}
+
+// TopSellerComparison is a best seller by revenue next to its sales in the comparison period
+type TopSellerComparison struct {
+	Rank         int         `json:"rank"`
+	PreviousRank *int        `json:"previous_rank"` // Rank by revenue in the comparison period, nil when not sold
+	ProductID    uint        `json:"product_id"`
+	Name         string      `json:"nama"`
+	Revenue      MetricDelta `json:"revenue"`
+	QtySold      MetricDelta `json:"qty_terjual"`
+}
+
+type ReportComparison struct {
+	Mode           string                `json:"mode"`
+	Start          time.Time             `json:"start"` // Inclusive, in the store timezone
+	End            time.Time             `json:"end"`   // Exclusive
+	Source         string                `json:"source"`
+	TotalRevenue   MetricDelta           `json:"total_revenue"`
+	TotalTransaksi MetricDelta           `json:"total_transaksi"`
+	AverageBasket  MetricDelta           `json:"average_basket"`
+	GrossProfit    MetricDelta           `json:"gross_profit"`
+	TopProducts    []TopSellerComparison `json:"top_products"`
+}
+
+const (
+	IntervalHour  = "hour"
+	IntervalDay   = "day"
+	IntervalWeek  = "week" // Weeks start on Monday
+	IntervalMonth = "month"
+)
+
+// SalesBucket is one point of the sales time series
+type SalesBucket struct {
+	Start        time.Time `json:"start"`
+	Revenue      int       `json:"revenue"`
+	Transactions int       `json:"transactions"`
+	ItemsSold    float64   `json:"items_sold"`
+}
+
+type SalesTimeSeries struct {
+	Interval string        `json:"interval"`
+	Timezone string        `json:"timezone"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Buckets  []SalesBucket `json:"buckets"`
+}
+
+// HeatmapCell holds the sales of one hour of one weekday over the whole range
+type HeatmapCell struct {
+	Revenue      int `json:"revenue"`
+	Transactions int `json:"transactions"`
+}
+
+type HeatmapDay struct {
+	Weekday int             `json:"weekday"` // ISO weekday, 1 = Monday
+	Name    string          `json:"name"`
+	Hours   [24]HeatmapCell `json:"hours"`
+}
+
+// SalesHeatmap is an hour-of-day by day-of-week matrix, Monday first
+type SalesHeatmap struct {
+	Timezone string       `json:"timezone"`
+	Start    time.Time    `json:"start"`
+	End      time.Time    `json:"end"`
+	Days     []HeatmapDay `json:"days"`
 }
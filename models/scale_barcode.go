@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ScaleValueWeight = "weight"
+	ScaleValuePrice  = "price"
+)
+
+// ScaleBarcodeRule describes how an in-store EAN-13 printed by a scale is laid out:
+// prefix (2) + PLU (PLULength) + optional ignored digits + value (ValueLength) + check digit (1)
+type ScaleBarcodeRule struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Prefix        string    `json:"prefix" gorm:"size:2;not null;uniqueIndex"`
+	PLULength     int       `json:"plu_length" gorm:"not null"`
+	ValueType     string    `json:"value_type" gorm:"size:10;not null"`
+	ValueLength   int       `json:"value_length" gorm:"not null"`
+	ValueDecimals int       `json:"value_decimals" gorm:"not null;default:0"` // e.g. 3 turns grams into kg
+	Description   string    `json:"description" gorm:"size:100"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type ScaleBarcodeRuleRequest struct {
+	Prefix        string `json:"prefix" binding:"required,len=2,numeric"`
+	PLULength     int    `json:"plu_length" binding:"required,min=3,max=6"`
+	ValueType     string `json:"value_type" binding:"required,oneof=weight price"`
+	ValueLength   int    `json:"value_length" binding:"required,min=3,max=6"`
+	ValueDecimals int    `json:"value_decimals" binding:"min=0,max=3"`
+	Description   string `json:"description" binding:"max=100"`
+}
+
+// ScaleReading is the data decoded from a scale-printed barcode
+type ScaleReading struct {
+	Prefix    string  `json:"prefix"`
+	PLU       string  `json:"plu"`
+	ValueType string  `json:"value_type"`
+	Quantity  float64 `json:"quantity"`
+	Price     float64 `json:"price,omitempty"`
+}
+
+// ScanResult is a product found by barcode, with the scale reading when the code came from a scale label
+type ScanResult struct {
+	*Product
+	Scale *ScaleReading `json:"scale,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	StockMovementReceive    = "receive"
+	StockMovementAdjustment = "adjustment" // Manual stock correction through product update
+)
+
+// StockMovement records every change to a product's stock outside of checkout
+type StockMovement struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ProductID  uint      `json:"product_id" gorm:"not null;index"`
+	Type       string    `json:"type" gorm:"size:20;not null;index"`
+	Quantity   float64   `json:"quantity" gorm:"type:numeric(14,3);not null"`
+	UnitCost   float64   `json:"unit_cost" gorm:"not null;default:0"`
+	StockAfter float64   `json:"stock_after" gorm:"type:numeric(14,3);not null"`
+	CostAfter  float64   `json:"cost_after" gorm:"not null;default:0"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+type ReceiveStockRequest struct {
+	Quantity float64 `json:"quantity" binding:"required,gt=0"`
+	Unit     string  `json:"unit"`                      // Defaults to the product's base unit
+	UnitCost float64 `json:"unit_cost" binding:"gte=0"` // Cost per received unit
+}
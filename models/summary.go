@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// DailySalesSummary holds the sales totals of one store-local day. It is kept up to date on
+// checkout and can be rebuilt from the transactions with the rebuild-summaries command.
+type DailySalesSummary struct {
+	Day          time.Time `json:"day" gorm:"type:date;primaryKey"`
+	Revenue      int       `json:"revenue" gorm:"not null;default:0"`
+	Transactions int       `json:"transactions" gorm:"not null;default:0"`
+	ItemsSold    float64   `json:"items_sold" gorm:"type:numeric(14,3);not null;default:0"`
+	COGS         int       `json:"cogs" gorm:"column:cogs;not null;default:0"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DailyProductSummary holds the sales of one product on one store-local day
+type DailyProductSummary struct {
+	Day         time.Time `json:"day" gorm:"type:date;primaryKey"`
+	ProductID   uint      `json:"product_id" gorm:"primaryKey;index"`
+	ProductName string    `json:"product_name" gorm:"size:100"`
+	QtySold     float64   `json:"qty_sold" gorm:"type:numeric(14,3);not null;default:0"`
+	Revenue     int       `json:"revenue" gorm:"not null;default:0"`
+	COGS        int       `json:"cogs" gorm:"column:cogs;not null;default:0"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
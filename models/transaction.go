@@ -7,7 +7,7 @@ import (
 type Transaction struct {
 	ID          uint                `json:"id" gorm:"primaryKey"`
 	TotalAmount int                 `json:"total_amount" gorm:"not null"`
-	CreatedAt   time.Time           `json:"created_at"`
+	CreatedAt   time.Time           `json:"created_at" gorm:"index"`
 	Details     []TransactionDetail `json:"details" gorm:"foreignKey:TransactionID"`
 }
 
@@ -16,14 +16,20 @@ type TransactionDetail struct {
 	TransactionID uint     `json:"transaction_id" gorm:"not null;index"`
 	ProductID     uint     `json:"product_id" gorm:"not null;index"`
 	ProductName   string   `json:"product_name,omitempty" gorm:"size:100"`
-	Quantity      int      `json:"quantity" gorm:"not null"`
+	Quantity      float64  `json:"quantity" gorm:"type:numeric(14,3);not null"` // In product base unit
+	Unit          string   `json:"unit,omitempty" gorm:"size:20"`
+	UnitQuantity  float64  `json:"unit_quantity,omitempty" gorm:"type:numeric(14,3)"` // Quantity in the unit it was sold in
 	Subtotal      int      `json:"subtotal" gorm:"not null"`
+	CostPrice     float64  `json:"cost_price" gorm:"not null;default:0"`    // Product cost per unit at time of sale
+	CostSubtotal  int      `json:"cost_subtotal" gorm:"not null;default:0"` // CostPrice * Quantity
 	Product       *Product `json:"product,omitempty" gorm:"foreignKey:ProductID"`
 }
 
 type CheckoutItem struct {
-	ProductID uint `json:"product_id"`
-	Quantity  int  `json:"quantity"`
+	ProductID uint    `json:"product_id"`
+	Barcode   string  `json:"barcode,omitempty"` // Alternative to product_id for scanned items
+	Quantity  float64 `json:"quantity"`
+	Unit      string  `json:"unit,omitempty"` // Defaults to the product's base unit
 }
 
 type CheckoutRequest struct {
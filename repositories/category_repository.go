@@ -0,0 +1,229 @@
+package repositories
+
+import (
+	"Kasir-API/models"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// categorySubtreeSQL selects a category and all of its descendants. UNION drops rows already
+// visited, so a cycle in the data ends the recursion instead of looping forever.
+const categorySubtreeSQL = `WITH RECURSIVE subtree AS (
+	SELECT id FROM categories WHERE id = ?
+	UNION
+	SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+) SELECT id FROM subtree`
+
+// categoryAncestorsSQL selects a category and all of its ancestors up to the root, stopping at
+// rows already visited like categorySubtreeSQL
+const categoryAncestorsSQL = `WITH RECURSIVE ancestors AS (
+	SELECT id, parent_id FROM categories WHERE id = ?
+	UNION
+	SELECT categories.id, categories.parent_id FROM categories JOIN ancestors ON categories.id = ancestors.parent_id
+) SELECT id FROM ancestors`
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// WithTreeLock runs fn with a repository bound to a transaction that holds the category tree
+// lock. Parent checks and the writes relying on them then cannot interleave with another move,
+// which could otherwise commit a cycle. Plain reads of categories are not blocked.
+func (r *CategoryRepository) WithTreeLock(fn func(repo *CategoryRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		return fn(&CategoryRepository{db: tx})
+	})
+}
+
+func (r *CategoryRepository) GetAll() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Select("id", "name", "description", "parent_id", "created_at", "updated_at").
+		Order("name").
+		Find(&categories).Error
+	return categories, err
+}
+
+// List returns one page of active categories, or only archived ones when archived is true
+func (r *CategoryRepository) List(archived bool, page models.Page) ([]models.Category, models.PageInfo, error) {
+	var categories []models.Category
+	query := r.db.Model(&models.Category{}).Select("id", "name", "description", "parent_id", "created_at", "updated_at", "deleted_at")
+	if archived {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	info, err := paginate(query, page, listOrder{table: "categories", terms: []string{"id"}}, &categories)
+	return categories, info, err
+}
+
+// FindByID returns an active category
+func (r *CategoryRepository) FindByID(id uint) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// FindAnyByID returns a category whether or not it is archived
+func (r *CategoryRepository) FindAnyByID(id uint) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.Unscoped().First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// FindByName returns the category with the given name, archived ones included since they keep their name
+func (r *CategoryRepository) FindByName(name string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.Unscoped().Where("name = ?", name).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// AncestorIDs returns the category itself followed by its parents up to the root
+func (r *CategoryRepository) AncestorIDs(id uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Raw(categoryAncestorsSQL, id).Scan(&ids).Error
+	return ids, err
+}
+
+func (r *CategoryRepository) Create(category *models.Category) error {
+	return r.db.Create(category).Error
+}
+
+func (r *CategoryRepository) Save(category *models.Category) error {
+	return r.db.Save(category).Error
+}
+
+// Restore brings back an archived category
+func (r *CategoryRepository) Restore(id uint) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&category, id).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.db.Unscoped().Model(&category).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	category.DeletedAt = gorm.DeletedAt{}
+
+	return &category, nil
+}
+
+// countReferences counts products and subcategories pointing at the category. Archived ones only
+// count when includeArchived is set, they still block removing the row but not archiving it.
+func countReferences(db *gorm.DB, id uint, includeArchived bool) (products int64, children int64, err error) {
+	if includeArchived {
+		db = db.Unscoped()
+	}
+	if err = db.Model(&models.Product{}).Where("category_id = ?", id).Count(&products).Error; err != nil {
+		return
+	}
+	err = db.Model(&models.Category{}).Where("parent_id = ?", id).Count(&children).Error
+	return
+}
+
+// Delete moves products and subcategories to targetID (when given) and then archives or
+// permanently deletes the category, all in one transaction. Without a target, check is called
+// with what still references the category and its error aborts the delete. The category row is
+// locked first so no product or subcategory can be added to it in between.
+func (r *CategoryRepository) Delete(category *models.Category, targetID *uint, permanent bool, check func(products, children int64) error) (*models.CategoryDeleteResult, error) {
+	result := models.CategoryDeleteResult{
+		ID:               category.ID,
+		Permanent:        permanent,
+		TargetCategoryID: targetID,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(category, category.ID).Error; err != nil {
+			return err
+		}
+
+		if targetID == nil {
+			products, children, err := countReferences(tx, category.ID, permanent)
+			if err != nil {
+				return err
+			}
+			if err := check(products, children); err != nil {
+				return err
+			}
+		}
+
+		if targetID != nil {
+			moved := tx.Unscoped().Model(&models.Product{}).
+				Where("category_id = ?", category.ID).
+				Update("category_id", *targetID)
+			if moved.Error != nil {
+				return moved.Error
+			}
+			result.ProductsMoved = moved.RowsAffected
+
+			moved = tx.Unscoped().Model(&models.Category{}).
+				Where("parent_id = ?", category.ID).
+				Update("parent_id", *targetID)
+			if moved.Error != nil {
+				return moved.Error
+			}
+			result.CategoriesMoved = moved.RowsAffected
+		}
+
+		if permanent {
+			return tx.Unscoped().Delete(category).Error
+		}
+		return tx.Delete(category).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// categoryChains loads all categories and returns a lookup of the chain from the root down to a
+// category. Archived categories are included so historic data keeps its place in the tree.
+func categoryChains(db *gorm.DB) (func(id uint) []models.Category, error) {
+	var categories []models.Category
+	if err := db.Unscoped().Select("id", "name", "parent_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	return func(id uint) []models.Category {
+		var chain []models.Category
+		for id != 0 && len(chain) <= len(byID) {
+			category, ok := byID[id]
+			if !ok {
+				break
+			}
+			chain = append([]models.Category{category}, chain...)
+			if category.ParentID == nil {
+				break
+			}
+			id = *category.ParentID
+		}
+		return chain
+	}, nil
+}
+
+// categoryPath joins a category chain into "Minuman > Kopi"
+func categoryPath(chain []models.Category) string {
+	names := make([]string, len(chain))
+	for i, category := range chain {
+		names[i] = category.Name
+	}
+	return strings.Join(names, " > ")
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"Kasir-API/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExportRepository struct {
+	db *gorm.DB
+}
+
+func NewExportRepository(db *gorm.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+// EachTransactionLine streams the lines of transactions made in [start, end), oldest first.
+// Rows are read from a cursor one at a time so the range can be arbitrarily large.
+func (r *ExportRepository) EachTransactionLine(start, end time.Time, fn func(line *models.TransactionExportLine) error) error {
+	rows, err := r.db.Table("transaction_details d").
+		Select(`t.id AS transaction_id, t.created_at, t.total_amount, d.id AS line_id, d.product_id,
+			COALESCE(NULLIF(d.product_name, ''), p.name) AS product_name, p.sku, d.quantity, p.base_unit,
+			d.unit, d.unit_quantity, d.subtotal, d.cost_subtotal`).
+		Joins("JOIN transactions t ON t.id = d.transaction_id").
+		Joins("LEFT JOIN products p ON p.id = d.product_id").
+		Where("t.created_at >= ? AND t.created_at < ?", start, end).
+		Order("t.created_at, t.id, d.id").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line models.TransactionExportLine
+		if err := r.db.ScanRows(rows, &line); err != nil {
+			return err
+		}
+		if err := fn(&line); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachProduct streams the active products with their category path, ordered by name
+func (r *ExportRepository) EachProduct(fn func(product *models.ProductExportRow) error) error {
+	chains, err := categoryChains(r.db)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.db.Model(&models.Product{}).
+		Select(`products.id, products.name, products.sku, products.plu, products.category_id,
+			products.measure_type, products.base_unit, products.price, products.cost_price, products.stock,
+			COALESCE((SELECT STRING_AGG(b.code, ', ' ORDER BY b.id) FROM product_barcodes b WHERE b.product_id = products.id), '') AS barcodes`).
+		Order("products.name, products.id").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product models.ProductExportRow
+		if err := r.db.ScanRows(rows, &product); err != nil {
+			return err
+		}
+		product.Category = categoryPath(chains(product.CategoryID))
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,443 @@
+package repositories
+
+import (
+	"Kasir-API/models"
+	"Kasir-API/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// errImportRolledBack ends the import transaction without it being a failure
+var errImportRolledBack = errors.New("import rolled back")
+
+type ImportRepository struct {
+	db *gorm.DB
+}
+
+func NewImportRepository(db *gorm.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+// productImport holds the state of one import run inside its transaction
+type productImport struct {
+	tx                *gorm.DB
+	now               time.Time
+	categories        map[string]*models.Category // By lower-case name, names are unique
+	categoriesCreated []string
+	skus              map[string]int // Lower-case SKU to the row that uses it
+	names             map[string]int // Lower-case name to the row that uses it, rows without SKU
+	plus              map[string]int
+	barcodes          map[string]int
+}
+
+// ImportProducts creates or updates the products of the rows in one transaction. Rows are matched
+// to existing products by SKU, or by name when they have none. Everything is rolled back again
+// when apply is false or any row has errors, so a dry run reports exactly what applying would do.
+func (r *ImportRepository) ImportProducts(rows []models.ProductImportRow, apply bool) ([]models.ProductImportRowResult, []string, error) {
+	results := make([]models.ProductImportRowResult, len(rows))
+	var categoriesCreated []string
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Keep other writers from creating conflicting products while the import is checked and applied
+		if apply {
+			if err := tx.Exec("LOCK TABLE products, categories IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+				return err
+			}
+		}
+
+		state := &productImport{
+			tx:         tx,
+			now:        time.Now(),
+			categories: make(map[string]*models.Category),
+			skus:       make(map[string]int),
+			names:      make(map[string]int),
+			plus:       make(map[string]int),
+			barcodes:   make(map[string]int),
+		}
+
+		var categories []models.Category
+		if err := tx.Unscoped().Find(&categories).Error; err != nil {
+			return err
+		}
+		for i := range categories {
+			state.categories[strings.ToLower(categories[i].Name)] = &categories[i]
+		}
+
+		failed := false
+		for i := range rows {
+			result, err := state.importRow(&rows[i])
+			if err != nil {
+				return fmt.Errorf("row %d: %w", rows[i].Row, err)
+			}
+			results[i] = result
+			failed = failed || len(result.Errors) > 0
+		}
+		categoriesCreated = state.categoriesCreated
+
+		if failed || !apply {
+			return errImportRolledBack
+		}
+		return nil
+	})
+	if errors.Is(err, errImportRolledBack) {
+		err = nil
+	}
+
+	return results, categoriesCreated, err
+}
+
+// importRow checks a row against the database and the rows before it and writes it when valid.
+// Row problems end up in the result, only database failures are returned as error.
+func (s *productImport) importRow(row *models.ProductImportRow) (models.ProductImportRowResult, error) {
+	result := models.ProductImportRowResult{Row: row.Row, Name: row.Name, SKU: row.SKU, Errors: row.Errors}
+	fail := func(format string, args ...interface{}) {
+		result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+	}
+
+	existing, err := s.matchProduct(row, fail)
+	if err != nil {
+		return result, err
+	}
+
+	if existing == nil {
+		if row.Price == nil {
+			fail("price is required for new products")
+		}
+		if row.Category == "" {
+			fail("category is required for new products")
+		}
+	}
+
+	if err := s.checkIdentifiers(row, existing, fail); err != nil {
+		return result, err
+	}
+
+	var categoryID uint
+	if row.Category != "" && len(result.Errors) == 0 {
+		category, err := s.resolveCategory(row.Category, fail)
+		if err != nil {
+			return result, err
+		}
+		if category != nil {
+			categoryID = category.ID
+		}
+	}
+
+	precision := 0
+	if existing != nil {
+		precision = existing.QuantityPrecision
+	} else if row.MeasureType == models.MeasureWeight || row.MeasureType == models.MeasureMeasure {
+		// Weighed and measured items default to 3 decimals, counted items to whole numbers
+		precision = models.MaxQuantityPrecision
+	}
+	if row.QuantityPrecision != nil {
+		precision = *row.QuantityPrecision
+	}
+	if row.Stock != nil && utils.RoundTo(*row.Stock, precision) != *row.Stock {
+		fail("stock has more decimals than quantity_precision %d allows", precision)
+	}
+
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	if existing == nil {
+		product, err := s.createProduct(row, categoryID, precision)
+		if err != nil {
+			return result, err
+		}
+		result.Action = models.ImportActionCreate
+		result.ProductID = product.ID
+		return result, nil
+	}
+
+	if err := s.updateProduct(existing, row, categoryID); err != nil {
+		return result, err
+	}
+	result.Action = models.ImportActionUpdate
+	result.ProductID = existing.ID
+	return result, nil
+}
+
+// matchProduct finds the product a row updates, nil when it creates a new one
+func (s *productImport) matchProduct(row *models.ProductImportRow, fail func(string, ...interface{})) (*models.Product, error) {
+	if row.SKU != "" {
+		key := strings.ToLower(row.SKU)
+		if previous, ok := s.skus[key]; ok {
+			fail("SKU %s is already used in row %d", row.SKU, previous)
+			return nil, nil
+		}
+		s.skus[key] = row.Row
+
+		var products []models.Product
+		if err := s.tx.Unscoped().Where("sku = ?", row.SKU).Limit(1).Find(&products).Error; err != nil {
+			return nil, err
+		}
+		if len(products) == 0 {
+			return nil, nil
+		}
+		if products[0].DeletedAt.Valid {
+			fail("SKU %s belongs to an archived product, restore it first", row.SKU)
+			return nil, nil
+		}
+		return &products[0], nil
+	}
+
+	key := strings.ToLower(row.Name)
+	if previous, ok := s.names[key]; ok {
+		fail("product %s is already in row %d, give the rows a SKU to tell them apart", row.Name, previous)
+		return nil, nil
+	}
+	s.names[key] = row.Row
+
+	var products []models.Product
+	if err := s.tx.Where("LOWER(name) = ?", key).Limit(2).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	switch len(products) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &products[0], nil
+	}
+	fail("several products are named %s, add the SKU to choose one", row.Name)
+	return nil, nil
+}
+
+// checkIdentifiers makes sure the PLU, barcodes and SKU are not used by another product or row
+func (s *productImport) checkIdentifiers(row *models.ProductImportRow, existing *models.Product, fail func(string, ...interface{})) error {
+	var productID uint
+	if existing != nil {
+		productID = existing.ID
+	}
+
+	if row.PLU != nil {
+		plu := *row.PLU
+		if previous, ok := s.plus[plu]; ok {
+			fail("PLU %s is already used in row %d", plu, previous)
+		} else {
+			s.plus[plu] = row.Row
+			var count int64
+			if err := s.tx.Unscoped().Model(&models.Product{}).Where("plu = ? AND id != ?", plu, productID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				fail("PLU %s already exists", plu)
+			}
+		}
+	}
+
+	// Scans look codes up as barcode or SKU, so neither may stand for another product in the other role
+	if row.SKU != "" {
+		if previous, ok := s.barcodes[row.SKU]; ok {
+			fail("SKU %s is already a barcode in row %d", row.SKU, previous)
+		} else {
+			var count int64
+			if err := s.tx.Model(&models.ProductBarcode{}).Where("code = ? AND product_id != ?", row.SKU, productID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				fail("SKU %s is already a barcode of another product", row.SKU)
+			}
+		}
+	}
+
+	if row.Barcodes != nil {
+		for _, code := range *row.Barcodes {
+			if previous, ok := s.barcodes[code]; ok {
+				fail("barcode %s is already used in row %d", code, previous)
+				continue
+			}
+			if previous, ok := s.skus[strings.ToLower(code)]; ok && previous != row.Row {
+				fail("barcode %s is already the SKU in row %d", code, previous)
+				continue
+			}
+			s.barcodes[code] = row.Row
+		}
+
+		var existingCode []string
+		if err := s.tx.Model(&models.ProductBarcode{}).Where("code IN ? AND product_id != ?", *row.Barcodes, productID).
+			Limit(1).Pluck("code", &existingCode).Error; err != nil {
+			return err
+		}
+		if len(existingCode) > 0 {
+			fail("barcode %s already exists", existingCode[0])
+		}
+
+		var existingSKU []string
+		if err := s.tx.Unscoped().Model(&models.Product{}).Where("sku IN ? AND id != ?", *row.Barcodes, productID).
+			Limit(1).Pluck("sku", &existingSKU).Error; err != nil {
+			return err
+		}
+		if len(existingSKU) > 0 {
+			fail("barcode %s is already the SKU of another product", existingSKU[0])
+		}
+	}
+
+	return nil
+}
+
+// resolveCategory walks a "Parent > Child" path, creating the categories that do not exist yet.
+// The first name may be anywhere in the tree, every following one must be a child of the previous.
+func (s *productImport) resolveCategory(path string, fail func(string, ...interface{})) (*models.Category, error) {
+	var parent *models.Category
+	var walked []string
+	for _, name := range strings.Split(path, ">") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			fail("category %q has an empty name", path)
+			return nil, nil
+		}
+		walked = append(walked, name)
+
+		category, ok := s.categories[strings.ToLower(name)]
+		if ok {
+			if category.DeletedAt.Valid {
+				fail("category %s is archived", category.Name)
+				return nil, nil
+			}
+			if parent != nil && (category.ParentID == nil || *category.ParentID != parent.ID) {
+				fail("category %s already exists outside %s", category.Name, parent.Name)
+				return nil, nil
+			}
+			parent = category
+			continue
+		}
+
+		category = &models.Category{Name: name}
+		if parent != nil {
+			category.ParentID = &parent.ID
+		}
+		if err := s.tx.Create(category).Error; err != nil {
+			return nil, err
+		}
+		s.categories[strings.ToLower(name)] = category
+		s.categoriesCreated = append(s.categoriesCreated, strings.Join(walked, " > "))
+		parent = category
+	}
+	return parent, nil
+}
+
+func (s *productImport) createProduct(row *models.ProductImportRow, categoryID uint, precision int) (*models.Product, error) {
+	product := models.Product{
+		Name:              row.Name,
+		Price:             *row.Price,
+		PriceChangedAt:    &s.now,
+		MeasureType:       models.MeasureCount,
+		QuantityPrecision: precision,
+		BaseUnit:          "pcs",
+		CategoryID:        categoryID,
+	}
+	if row.SKU != "" {
+		product.SKU = &row.SKU
+	}
+	product.PLU = row.PLU
+	if row.CostPrice != nil {
+		product.CostPrice = *row.CostPrice
+	}
+	if row.Stock != nil {
+		product.Stock = *row.Stock
+	}
+	if row.MeasureType != "" {
+		product.MeasureType = row.MeasureType
+	}
+	if row.BaseUnit != "" {
+		product.BaseUnit = row.BaseUnit
+	}
+	if row.Barcodes != nil {
+		for _, code := range *row.Barcodes {
+			product.Barcodes = append(product.Barcodes, models.ProductBarcode{Code: code})
+		}
+	}
+
+	// Start the price history with the initial price
+	product.Prices = []models.ProductPrice{{Price: product.Price, EffectiveFrom: s.now, Applied: true}}
+
+	return &product, s.tx.Create(&product).Error
+}
+
+// updateProduct applies the filled fields of a row the same way PUT /products/{id} does
+func (s *productImport) updateProduct(product *models.Product, row *models.ProductImportRow, categoryID uint) error {
+	updates := make(map[string]interface{})
+
+	if row.Name != product.Name {
+		updates["name"] = row.Name
+	}
+
+	if row.PLU != nil {
+		updates["plu"] = *row.PLU
+	}
+
+	priceChanged := row.Price != nil && *row.Price != product.Price
+	if priceChanged {
+		updates["price"] = *row.Price
+		updates["price_changed_at"] = s.now
+	}
+
+	if row.CostPrice != nil {
+		updates["cost_price"] = *row.CostPrice
+	}
+	if row.Stock != nil {
+		updates["stock"] = *row.Stock
+	}
+	if row.MeasureType != "" {
+		updates["measure_type"] = row.MeasureType
+	}
+	if row.QuantityPrecision != nil {
+		updates["quantity_precision"] = *row.QuantityPrecision
+	}
+	if row.BaseUnit != "" {
+		updates["base_unit"] = row.BaseUnit
+	}
+	if categoryID != 0 {
+		updates["category_id"] = categoryID
+	}
+
+	if len(updates) > 0 {
+		if err := s.tx.Model(product).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	// Manual stock corrections are recorded so past stock levels can be reconstructed
+	if row.Stock != nil && *row.Stock != product.Stock {
+		cost := product.CostPrice
+		if row.CostPrice != nil {
+			cost = *row.CostPrice
+		}
+		adjustment := models.StockMovement{
+			ProductID:  product.ID,
+			Type:       models.StockMovementAdjustment,
+			Quantity:   utils.RoundTo(*row.Stock-product.Stock, models.MaxQuantityPrecision),
+			UnitCost:   cost,
+			StockAfter: *row.Stock,
+			CostAfter:  cost,
+		}
+		if err := s.tx.Create(&adjustment).Error; err != nil {
+			return err
+		}
+	}
+
+	if priceChanged {
+		if err := s.tx.Create(&models.ProductPrice{ProductID: product.ID, Price: *row.Price, EffectiveFrom: s.now, Applied: true}).Error; err != nil {
+			return err
+		}
+	}
+
+	if row.Barcodes != nil {
+		if err := s.tx.Where("product_id = ?", product.ID).Delete(&models.ProductBarcode{}).Error; err != nil {
+			return err
+		}
+		for _, code := range *row.Barcodes {
+			if err := s.tx.Create(&models.ProductBarcode{ProductID: product.ID, Code: code}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
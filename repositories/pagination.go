@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"Kasir-API/models"
+	"Kasir-API/utils"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// listOrder is the ORDER BY of a paginated list. All terms share one direction and the last one is
+// the ID, so a cursor resumes after its row by comparing the terms with that row's own values.
+type listOrder struct {
+	table string
+	terms []string
+	vars  []interface{} // Arguments of the terms, in order
+	desc  bool
+}
+
+// sql renders the ORDER BY, flipped when reverse is set to walk the list backwards
+func (o listOrder) sql(reverse bool) string {
+	direction := " ASC"
+	if o.desc != reverse {
+		direction = " DESC"
+	}
+	return strings.Join(o.terms, direction+", ") + direction
+}
+
+// paginate counts the rows matched by query, then loads the requested page into dest, a pointer to
+// a slice, in the given order. With a cursor the page starts right after (or before) the cursor's
+// row, so rows added or removed on other pages don't shift it. A backward page is read in reverse
+// order and flipped back. One row beyond the page is fetched to tell whether another page follows.
+// Preloads go in scopes so they only run for the page query, not the count.
+func paginate(query *gorm.DB, page models.Page, order listOrder, dest interface{}, scopes ...func(*gorm.DB) *gorm.DB) (models.PageInfo, error) {
+	var info models.PageInfo
+	if err := query.Session(&gorm.Session{}).Count(&info.Total).Error; err != nil {
+		return info, err
+	}
+
+	backward := page.Before != 0
+	if cursor := page.After + page.Before; cursor != 0 {
+		var found int64
+		if err := query.Session(&gorm.Session{NewDB: true}).Table(order.table).Where("id = ?", cursor).Count(&found).Error; err != nil {
+			return info, err
+		}
+		if found == 0 {
+			return info, utils.ErrStaleCursor
+		}
+
+		terms := strings.Join(order.terms, ", ")
+		operator := ">"
+		if order.desc != backward {
+			operator = "<"
+		}
+		vars := append(append(append([]interface{}{}, order.vars...), order.vars...), cursor)
+		query = query.Where(fmt.Sprintf("(%s) %s (SELECT %s FROM %s WHERE id = ?)", terms, operator, terms, order.table), vars...)
+	}
+
+	query = query.Clauses(clause.OrderBy{
+		Expression: clause.Expr{SQL: order.sql(backward), Vars: order.vars, WithoutParentheses: true},
+	})
+
+	if err := query.Scopes(scopes...).Limit(page.Limit + 1).Offset(page.Offset).Find(dest).Error; err != nil {
+		return info, err
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() > page.Limit {
+		info.HasMore = true
+		rows.Set(rows.Slice(0, page.Limit))
+	}
+	if backward {
+		swap := reflect.Swapper(rows.Interface())
+		for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	return info, nil
+}
@@ -2,8 +2,13 @@ package repositories
 
 import (
 	"Kasir-API/models"
+	"Kasir-API/utils"
+	"errors"
+	"math"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository struct {
@@ -14,18 +19,339 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (r *ProductRepository) GetAll(nameFilter string) ([]models.Product, error) {
+// GetAll lists one page of active products, or only archived ones when filter.Archived is true
+func (r *ProductRepository) GetAll(filter models.ProductFilter, page models.Page) ([]models.Product, models.PageInfo, error) {
 	var products []models.Product
 
-	query := r.db.Select("id", "name", "price", "stock", "category_id", "created_at", "updated_at").
-		Preload("Category", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id", "name")
+	query := r.db.Model(&models.Product{}).Select("id", "name", "sku", "plu", "price", "price_changed_at", "cost_price", "stock", "base_unit", "measure_type", "quantity_precision", "category_id", "created_at", "updated_at")
+
+	if filter.Archived {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	if filter.Name != "" {
+		query = query.Where("name ILIKE ?", "%"+filter.Name+"%")
+	}
+
+	if filter.CategoryID != 0 {
+		query = query.Where("category_id IN ("+categorySubtreeSQL+")", filter.CategoryID)
+	}
+
+	// Trigram similarity tolerates typos, ILIKE keeps short partial words matching
+	if filter.Query != "" {
+		query = query.Where("(name % ? OR sku % ? OR name ILIKE ? OR sku ILIKE ?)",
+			filter.Query, filter.Query, "%"+filter.Query+"%", "%"+filter.Query+"%")
+	}
+
+	if filter.MinPrice != nil {
+		query = query.Where("price >= ?", *filter.MinPrice)
+	}
+
+	if filter.MaxPrice != nil {
+		query = query.Where("price <= ?", *filter.MaxPrice)
+	}
+
+	if filter.InStock {
+		query = query.Where("stock > 0")
+	}
+
+	if filter.LowStock && filter.LowStockThreshold != nil {
+		query = query.Where("stock <= ?", *filter.LowStockThreshold)
+	}
+
+	info, err := paginate(query, page, productOrder(filter), &products, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Category", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Select("id", "name", "deleted_at")
+		}).Preload("Barcodes", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "product_id", "code")
 		})
+	})
+	return products, info, err
+}
+
+// productSortColumns whitelists the columns products can be sorted by
+var productSortColumns = map[string]string{
+	"name":       "name",
+	"price":      "price",
+	"stock":      "stock",
+	"updated_at": "updated_at",
+}
+
+// productOrder sorts by the requested column, by relevance when searching, or by ID.
+// Ties are broken by ID in the same direction so cursors can resume after any product.
+func productOrder(filter models.ProductFilter) listOrder {
+	order := listOrder{table: "products", terms: []string{"id"}, desc: filter.Order == "desc"}
+
+	if column, ok := productSortColumns[filter.Sort]; ok {
+		order.terms = []string{column, "id"}
+		return order
+	}
 
-	if nameFilter != "" {
-		query = query.Where("name ILIKE ?", "%"+nameFilter+"%")
+	if filter.Query != "" && (filter.Sort == "" || filter.Sort == "relevance") {
+		return listOrder{
+			table: "products",
+			terms: []string{"GREATEST(similarity(name, ?), similarity(COALESCE(sku, ''), ?))", "id"},
+			vars:  []interface{}{filter.Query, filter.Query},
+			desc:  true,
+		}
 	}
 
-	err := query.Find(&products).Error
+	return order
+}
+
+// FindByID returns a product with its category, barcodes and units
+func (r *ProductRepository) FindByID(id uint) (*models.Product, error) {
+	var product models.Product
+
+	err := r.db.Preload("Category").Preload("Barcodes").Preload("Units").First(&product, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// FindByBarcode looks up a product by one of its barcodes, falling back to SKU
+func (r *ProductRepository) FindByBarcode(code string) (*models.Product, error) {
+	var product models.Product
+
+	// A barcode match wins over a SKU match, creating and updating products keeps the two apart
+	err := r.db.Preload("Category").Preload("Barcodes").Preload("Units").
+		Where("id IN (SELECT product_id FROM product_barcodes WHERE code = ?)", code).
+		First(&product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = r.db.Preload("Category").Preload("Barcodes").Preload("Units").
+			Where("sku = ?", code).
+			First(&product).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// GetForLabels returns products by ID and/or whose price changed since the given time
+func (r *ProductRepository) GetForLabels(ids []uint, changedSince *time.Time) ([]models.Product, error) {
+	var products []models.Product
+
+	query := r.db.Preload("Barcodes")
+
+	switch {
+	case len(ids) > 0 && changedSince != nil:
+		query = query.Where("id IN ? OR price_changed_at >= ?", ids, *changedSince)
+	case len(ids) > 0:
+		query = query.Where("id IN ?", ids)
+	case changedSince != nil:
+		query = query.Where("price_changed_at >= ?", *changedSince)
+	}
+
+	err := query.Order("name").Find(&products).Error
 	return products, err
 }
+
+// FindByPLU looks up a product by its scale PLU
+func (r *ProductRepository) FindByPLU(plu string) (*models.Product, error) {
+	var product models.Product
+
+	err := r.db.Preload("Category").Preload("Barcodes").Preload("Units").
+		Where("plu = ?", plu).
+		First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// GetPrices returns the price history of a product, scheduled entries first
+func (r *ProductRepository) GetPrices(productID uint, page models.Page) ([]models.ProductPrice, models.PageInfo, error) {
+	var prices []models.ProductPrice
+	query := r.db.Model(&models.ProductPrice{}).Where("product_id = ?", productID)
+	order := listOrder{table: "product_prices", terms: []string{"effective_from", "id"}, desc: true}
+	info, err := paginate(query, page, order, &prices)
+	return prices, info, err
+}
+
+// EffectivePrice returns the latest price that took effect at or before the given time
+func (r *ProductRepository) EffectivePrice(productID uint, at time.Time) (*models.ProductPrice, error) {
+	var price models.ProductPrice
+	err := r.db.Where("product_id = ? AND effective_from <= ?", productID, at).
+		Order("effective_from DESC, id DESC").
+		First(&price).Error
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// AddPrice records a price change. Changes that are already effective update the product immediately,
+// unless a later change is already in effect, so a backdated entry only fills in the history.
+func (r *ProductRepository) AddPrice(entry *models.ProductPrice, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, entry.ProductID); err != nil {
+			return err
+		}
+		entry.Applied = !entry.EffectiveFrom.After(now)
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return syncEffectivePrice(tx, entry.ProductID, now)
+	})
+}
+
+func (r *ProductRepository) FindPrice(productID, priceID uint) (*models.ProductPrice, error) {
+	var price models.ProductPrice
+	if err := r.db.Where("product_id = ?", productID).First(&price, priceID).Error; err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// DeletePrice removes a price entry and puts the product back on the price in effect without it
+func (r *ProductRepository) DeletePrice(price *models.ProductPrice, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, price.ProductID); err != nil {
+			return err
+		}
+		if err := tx.Delete(price).Error; err != nil {
+			return err
+		}
+		return syncEffectivePrice(tx, price.ProductID, now)
+	})
+}
+
+func lockProduct(tx *gorm.DB, productID uint) error {
+	var product models.Product
+	return tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&product, productID).Error
+}
+
+// syncEffectivePrice sets products.price to the latest history entry in effect at now. Products
+// without any entry in effect keep their price.
+func syncEffectivePrice(tx *gorm.DB, productID uint, now time.Time) error {
+	var price models.ProductPrice
+	err := tx.Where("product_id = ? AND effective_from <= ?", productID, now).
+		Order("effective_from DESC, id DESC").
+		First(&price).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Model(&models.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"price":            price.Price,
+		"price_changed_at": price.EffectiveFrom,
+	}).Error
+}
+
+// ApplyDuePrices copies scheduled prices that have become effective to their products
+// and returns how many products were updated
+func (r *ProductRepository) ApplyDuePrices(now time.Time) (int, error) {
+	updated := 0
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var due []models.ProductPrice
+		if err := tx.Where("applied = ? AND effective_from <= ?", false, now).
+			Order("product_id, effective_from, id").
+			Find(&due).Error; err != nil {
+			return err
+		}
+
+		if len(due) == 0 {
+			return nil
+		}
+
+		// Only the most recent due entry per product matters
+		latest := make(map[uint]models.ProductPrice)
+		ids := make([]uint, 0, len(due))
+		for _, price := range due {
+			latest[price.ProductID] = price
+			ids = append(ids, price.ID)
+		}
+
+		for productID, price := range latest {
+			// Skip when a newer price was already applied directly
+			result := tx.Unscoped().Model(&models.Product{}).
+				Where("id = ? AND (price_changed_at IS NULL OR price_changed_at <= ?)", productID, price.EffectiveFrom).
+				Updates(map[string]interface{}{
+					"price":            price.Price,
+					"price_changed_at": price.EffectiveFrom,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			updated += int(result.RowsAffected)
+		}
+
+		return tx.Model(&models.ProductPrice{}).Where("id IN ?", ids).Update("applied", true).Error
+	})
+
+	return updated, err
+}
+
+// ReceiveStock adds incoming stock and recalculates the weighted moving average cost
+func (r *ProductRepository) ReceiveStock(productID uint, quantity float64, unitCost float64) (*models.Product, error) {
+	var product models.Product
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+			return err
+		}
+
+		newStock := utils.RoundTo(product.Stock+quantity, models.MaxQuantityPrecision)
+		newCost := unitCost
+		if product.Stock > 0 {
+			newCost = (product.Stock*product.CostPrice + quantity*unitCost) / newStock
+		}
+		newCost = math.Round(newCost*100) / 100
+
+		if err := tx.Model(&product).Updates(map[string]interface{}{
+			"stock":      newStock,
+			"cost_price": newCost,
+		}).Error; err != nil {
+			return err
+		}
+		product.Stock = newStock
+		product.CostPrice = newCost
+
+		return tx.Create(&models.StockMovement{
+			ProductID:  product.ID,
+			Type:       models.StockMovementReceive,
+			Quantity:   quantity,
+			UnitCost:   unitCost,
+			StockAfter: newStock,
+			CostAfter:  newCost,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *ProductRepository) GetUnits(productID uint, page models.Page) ([]models.ProductUnit, models.PageInfo, error) {
+	var units []models.ProductUnit
+	query := r.db.Model(&models.ProductUnit{}).Where("product_id = ?", productID)
+	order := listOrder{table: "product_units", terms: []string{"conversion_factor", "id"}}
+	info, err := paginate(query, page, order, &units)
+	return units, info, err
+}
+
+func (r *ProductRepository) FindUnit(productID, unitID uint) (*models.ProductUnit, error) {
+	var unit models.ProductUnit
+	if err := r.db.Where("product_id = ?", productID).First(&unit, unitID).Error; err != nil {
+		return nil, err
+	}
+	return &unit, nil
+}
+
+func (r *ProductRepository) SaveUnit(unit *models.ProductUnit) error {
+	return r.db.Save(unit).Error
+}
+
+func (r *ProductRepository) DeleteUnit(unit *models.ProductUnit) error {
+	return r.db.Delete(unit).Error
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"Kasir-API/models"
+
+	"gorm.io/gorm"
+)
+
+type PurchaseListRepository struct {
+	db *gorm.DB
+}
+
+func NewPurchaseListRepository(db *gorm.DB) *PurchaseListRepository {
+	return &PurchaseListRepository{db: db}
+}
+
+func (r *PurchaseListRepository) GetAll(page models.Page) ([]models.PurchaseList, models.PageInfo, error) {
+	var lists []models.PurchaseList
+	info, err := paginate(r.db.Model(&models.PurchaseList{}), page, listOrder{table: "purchase_lists", terms: []string{"created_at", "id"}, desc: true}, &lists)
+	return lists, info, err
+}
+
+func (r *PurchaseListRepository) FindByID(id uint) (*models.PurchaseList, error) {
+	var list models.PurchaseList
+	if err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("product_name, id")
+	}).First(&list, id).Error; err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Create saves the list together with its items
+func (r *PurchaseListRepository) Create(list *models.PurchaseList) error {
+	return r.db.Create(list).Error
+}
+
+// Delete removes the list and its items
+func (r *PurchaseListRepository) Delete(list *models.PurchaseList) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("purchase_list_id = ?", list.ID).Delete(&models.PurchaseListItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(list).Error
+	})
+}
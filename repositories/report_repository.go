@@ -0,0 +1,518 @@
+package repositories
+
+import (
+	"Kasir-API/models"
+	"Kasir-API/utils"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// BucketTotals is a raw aggregate row, Start is a wall-clock time in the store timezone
+type BucketTotals struct {
+	Start        time.Time
+	Revenue      int
+	Transactions int
+	ItemsSold    float64
+}
+
+// SalesByBucket sums sales in [start, end) per interval ('hour', 'day', 'week' or 'month'),
+// truncated in the given timezone. Buckets without sales are not returned.
+func (r *ReportRepository) SalesByBucket(interval, timezone string, start, end time.Time) ([]BucketTotals, error) {
+	var rows []BucketTotals
+	err := r.db.Raw(`
+		SELECT date_trunc(?, t.created_at AT TIME ZONE ?) AS start,
+			COALESCE(SUM(t.total_amount), 0) AS revenue,
+			COUNT(t.id) AS transactions,
+			COALESCE(SUM(d.items), 0) AS items_sold
+		FROM transactions t
+		LEFT JOIN (
+			SELECT transaction_id, SUM(quantity) AS items
+			FROM transaction_details
+			GROUP BY transaction_id
+		) d ON d.transaction_id = t.id
+		WHERE t.created_at >= ? AND t.created_at < ?
+		GROUP BY 1
+		ORDER BY 1`, interval, timezone, start, end).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// HeatmapTotals is the raw aggregate of one weekday and hour
+type HeatmapTotals struct {
+	Weekday      int
+	Hour         int
+	Revenue      int
+	Transactions int
+}
+
+// SalesByWeekdayHour sums sales in [start, end) per ISO weekday and hour in the given timezone
+func (r *ReportRepository) SalesByWeekdayHour(timezone string, start, end time.Time) ([]HeatmapTotals, error) {
+	var rows []HeatmapTotals
+	err := r.db.Raw(`
+		SELECT EXTRACT(ISODOW FROM created_at AT TIME ZONE ?)::int AS weekday,
+			EXTRACT(HOUR FROM created_at AT TIME ZONE ?)::int AS hour,
+			COALESCE(SUM(total_amount), 0) AS revenue,
+			COUNT(id) AS transactions
+		FROM transactions
+		WHERE created_at >= ? AND created_at < ?
+		GROUP BY 1, 2`, timezone, timezone, start, end).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// RegisterTotals sums the sales since the last Z-report for an X-report, reported as the period
+// [start, end). Tax fields are left to the caller.
+func (r *ReportRepository) RegisterTotals(start, end time.Time) (models.RegisterTotals, error) {
+	after, err := closedThrough(r.db)
+	if err != nil {
+		return models.RegisterTotals{}, err
+	}
+	through, err := lastTransactionID(r.db)
+	if err != nil {
+		return models.RegisterTotals{}, err
+	}
+	return registerTotals(r.db, after, through, start, end)
+}
+
+// closedThrough returns the highest transaction ID covered by a Z-report. Reports from before the
+// watermark was stored fall back to their last transaction.
+func closedThrough(db *gorm.DB) (uint, error) {
+	var id uint
+	err := db.Model(&models.ZReport{}).
+		Select("COALESCE(MAX(GREATEST(through_transaction_id, COALESCE(last_transaction_id, 0))), 0)").
+		Row().Scan(&id)
+	return id, err
+}
+
+func lastTransactionID(db *gorm.DB) (uint, error) {
+	var id uint
+	err := db.Model(&models.Transaction{}).Select("COALESCE(MAX(id), 0)").Row().Scan(&id)
+	return id, err
+}
+
+// registerTotals is shared by X- and Z-reports and sums the transactions with an ID in
+// (afterID, throughID]. IDs are used rather than timestamps because a checkout stamps created_at
+// before it commits. start and end only label the period, a zero start means "since the first
+// sale" and the period then starts at the first transaction.
+func registerTotals(db *gorm.DB, afterID, throughID uint, start, end time.Time) (models.RegisterTotals, error) {
+	totals := models.RegisterTotals{PeriodStart: start, PeriodEnd: end}
+	if start.IsZero() {
+		totals.PeriodStart = end
+	}
+
+	inPeriod := func(query *gorm.DB) *gorm.DB {
+		return query.Where("transactions.id > ? AND transactions.id <= ?", afterID, throughID)
+	}
+
+	row := inPeriod(db.Model(&models.Transaction{})).
+		Select("COALESCE(SUM(total_amount), 0), COUNT(id)").
+		Row()
+	if err := row.Scan(&totals.GrossSales, &totals.TransactionCount); err != nil {
+		return totals, err
+	}
+
+	if totals.TransactionCount == 0 {
+		return totals, nil
+	}
+
+	var first, last models.Transaction
+	if err := inPeriod(db.Select("id", "created_at")).Order("id").First(&first).Error; err != nil {
+		return totals, err
+	}
+	if err := inPeriod(db.Select("id", "created_at")).Order("id DESC").First(&last).Error; err != nil {
+		return totals, err
+	}
+	totals.FirstTransactionID, totals.FirstTransactionAt = &first.ID, &first.CreatedAt
+	if start.IsZero() {
+		totals.PeriodStart = first.CreatedAt
+	}
+	totals.LastTransactionID, totals.LastTransactionAt = &last.ID, &last.CreatedAt
+
+	row = inPeriod(db.Model(&models.TransactionDetail{}).
+		Select("COALESCE(SUM(transaction_details.quantity), 0)").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id")).
+		Row()
+	err := row.Scan(&totals.ItemsSold)
+	return totals, err
+}
+
+// LastZReport returns the most recent Z-report, or nil when the day has never been closed
+func (r *ReportRepository) LastZReport() (*models.ZReport, error) {
+	var report models.ZReport
+	err := r.db.Order("number DESC").First(&report).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// CloseDay stores a Z-report covering every transaction since the previous one. The z_reports table
+// is locked so two registers closing at once can't produce the same number or overlapping periods,
+// and transactions in SHARE mode so checkouts still in flight commit before the watermark is read
+// and later ones get a higher ID. build fills in the derived figures (tax, business date) before
+// the record is saved.
+func (r *ReportRepository) CloseDay(end time.Time, build func(report *models.ZReport)) (*models.ZReport, error) {
+	var report models.ZReport
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE z_reports IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("LOCK TABLE transactions IN SHARE MODE").Error; err != nil {
+			return err
+		}
+
+		var previous models.ZReport
+		start := time.Time{}
+		number := uint(1)
+		if err := tx.Order("number DESC").First(&previous).Error; err == nil {
+			start = previous.PeriodEnd
+			number = previous.Number + 1
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		after, err := closedThrough(tx)
+		if err != nil {
+			return err
+		}
+		through, err := lastTransactionID(tx)
+		if err != nil {
+			return err
+		}
+
+		totals, err := registerTotals(tx, after, through, start, end)
+		if err != nil {
+			return err
+		}
+
+		report = models.ZReport{Number: number, ThroughTransactionID: through, RegisterTotals: totals}
+		build(&report)
+
+		return tx.Create(&report).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (r *ReportRepository) GetZReports(page models.Page) ([]models.ZReport, models.PageInfo, error) {
+	var reports []models.ZReport
+	info, err := paginate(r.db.Model(&models.ZReport{}), page, listOrder{table: "z_reports", terms: []string{"number", "id"}, desc: true}, &reports)
+	return reports, info, err
+}
+
+func (r *ReportRepository) FindZReport(id uint) (*models.ZReport, error) {
+	var report models.ZReport
+	if err := r.db.First(&report, id).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// productValue is a per-product figure used to rewind stock, cost and price
+type productValue struct {
+	ProductID uint
+	Value     float64
+}
+
+// InventoryAt returns the stock of every product as of asOf, with cost and retail price at that
+// moment. A nil asOf returns the live figures. Past stock is rebuilt by undoing the stock movements
+// and sales recorded since then; past cost comes from the last movement before asOf, falling back
+// to the cost snapshot of the first later sale, and past price from the price history.
+func (r *ReportRepository) InventoryAt(asOf *time.Time) ([]models.InventoryValuationLine, error) {
+	var products []models.Product
+	query := r.db.Unscoped().Select("id", "name", "sku", "stock", "cost_price", "price", "base_unit", "category_id")
+	if asOf == nil {
+		query = query.Where("deleted_at IS NULL")
+	} else {
+		query = query.Where("created_at < ? AND (deleted_at IS NULL OR deleted_at >= ?)", *asOf, *asOf)
+	}
+	if err := query.Order("id").Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	path, err := categoryChains(r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]models.InventoryValuationLine, len(products))
+	for i, product := range products {
+		lines[i] = models.InventoryValuationLine{
+			ProductID:  product.ID,
+			Name:       product.Name,
+			SKU:        product.SKU,
+			CategoryID: product.CategoryID,
+			Category:   categoryPath(path(product.CategoryID)),
+			Quantity:   product.Stock,
+			BaseUnit:   product.BaseUnit,
+			UnitCost:   product.CostPrice,
+			UnitPrice:  product.Price,
+		}
+	}
+
+	if asOf == nil {
+		return lines, nil
+	}
+
+	movedSince, err := r.valuesByProduct(`SELECT product_id, SUM(quantity) AS value FROM stock_movements
+		WHERE created_at >= ? GROUP BY product_id`, *asOf)
+	if err != nil {
+		return nil, err
+	}
+	soldSince, err := r.valuesByProduct(`SELECT d.product_id, SUM(d.quantity) AS value FROM transaction_details d
+		JOIN transactions t ON t.id = d.transaction_id
+		WHERE t.created_at >= ? GROUP BY d.product_id`, *asOf)
+	if err != nil {
+		return nil, err
+	}
+	costBefore, err := r.valuesByProduct(`SELECT DISTINCT ON (product_id) product_id, cost_after AS value FROM stock_movements
+		WHERE created_at < ? ORDER BY product_id, created_at DESC, id DESC`, *asOf)
+	if err != nil {
+		return nil, err
+	}
+	costSoldAfter, err := r.valuesByProduct(`SELECT DISTINCT ON (d.product_id) d.product_id, d.cost_price AS value FROM transaction_details d
+		JOIN transactions t ON t.id = d.transaction_id
+		WHERE t.created_at >= ? ORDER BY d.product_id, t.created_at, d.id`, *asOf)
+	if err != nil {
+		return nil, err
+	}
+	priceAt, err := r.valuesByProduct(`SELECT DISTINCT ON (product_id) product_id, price AS value FROM product_prices
+		WHERE effective_from <= ? ORDER BY product_id, effective_from DESC, id DESC`, *asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range lines {
+		line := &lines[i]
+		line.Quantity = utils.RoundTo(line.Quantity-movedSince[line.ProductID]+soldSince[line.ProductID], models.MaxQuantityPrecision)
+
+		if cost, ok := costBefore[line.ProductID]; ok {
+			line.UnitCost = cost
+		} else if cost, ok := costSoldAfter[line.ProductID]; ok {
+			line.UnitCost = cost
+		}
+		if price, ok := priceAt[line.ProductID]; ok {
+			line.UnitPrice = price
+		}
+	}
+
+	return lines, nil
+}
+
+// valuesByProduct runs a query returning product_id and value columns and maps value by product
+func (r *ReportRepository) valuesByProduct(sql string, values ...interface{}) (map[uint]float64, error) {
+	var rows []productValue
+	if err := r.db.Raw(sql, values...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		result[row.ProductID] = row.Value
+	}
+	return result, nil
+}
+
+// SlowMovers returns active products with stock that did not sell since the given time, or whose
+// sell-through is below maxSellThrough percent, most capital tied up first. Sell-through is sold / (sold + stock on hand).
+// Products created after since are left out, they haven't had the full window to sell.
+func (r *ReportRepository) SlowMovers(since time.Time, maxSellThrough float64) ([]models.SlowMovingProduct, error) {
+	var rows []models.SlowMovingProduct
+	// The last sale is only looked up for products that made the list, one indexed probe each
+	err := r.db.Raw(`
+		WITH candidates AS (
+			SELECT p.id AS product_id, p.name, p.sku, p.category_id, p.stock, p.base_unit, p.cost_price,
+				COALESCE(w.qty, 0) AS qty_sold
+			FROM products p
+			LEFT JOIN (
+				SELECT d.product_id, SUM(d.quantity) AS qty
+				FROM transaction_details d
+				JOIN transactions t ON t.id = d.transaction_id
+				WHERE t.created_at >= ?
+				GROUP BY d.product_id
+			) w ON w.product_id = p.id
+			WHERE p.deleted_at IS NULL
+				AND p.stock > 0
+				AND p.created_at < ?
+				AND (w.qty IS NULL OR w.qty * 100 < ? * (w.qty + p.stock))
+		)
+		SELECT c.*, l.last_sold_at
+		FROM candidates c
+		LEFT JOIN LATERAL (
+			SELECT t.created_at AS last_sold_at
+			FROM transaction_details d
+			JOIN transactions t ON t.id = d.transaction_id
+			WHERE d.product_id = c.product_id
+			ORDER BY t.created_at DESC
+			LIMIT 1
+		) l ON true
+		ORDER BY c.stock * c.cost_price DESC, c.product_id`, since, since, maxSellThrough).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// basketsSQL lists each product once per transaction in [start, end)
+const basketsSQL = `baskets AS (
+	SELECT DISTINCT d.transaction_id, d.product_id
+	FROM transaction_details d
+	JOIN transactions t ON t.id = d.transaction_id
+	WHERE t.created_at >= @start AND t.created_at < @end
+), item_counts AS (
+	SELECT product_id, COUNT(*) AS cnt FROM baskets GROUP BY product_id
+)`
+
+// CountBaskets returns how many transactions were made in [start, end)
+func (r *ReportRepository) CountBaskets(start, end time.Time) (int, error) {
+	var count int64
+	err := r.db.Model(&models.Transaction{}).Where("created_at >= ? AND created_at < ?", start, end).Count(&count).Error
+	return int(count), err
+}
+
+// ProductPairs returns the pairs bought together in at least minTogether baskets, most frequent first
+func (r *ReportRepository) ProductPairs(start, end time.Time, minTogether, limit int) ([]models.ProductPair, error) {
+	var pairs []models.ProductPair
+	err := r.db.Raw(`WITH `+basketsSQL+`
+		SELECT pairs.product_a, pa.name AS name_a, pairs.product_b, pb.name AS name_b, pairs.together,
+			ca.cnt AS count_a, cb.cnt AS count_b
+		FROM (
+			SELECT a.product_id AS product_a, b.product_id AS product_b, COUNT(*) AS together
+			FROM baskets a
+			JOIN baskets b ON b.transaction_id = a.transaction_id AND a.product_id < b.product_id
+			GROUP BY a.product_id, b.product_id
+			HAVING COUNT(*) >= @min
+		) pairs
+		JOIN item_counts ca ON ca.product_id = pairs.product_a
+		JOIN item_counts cb ON cb.product_id = pairs.product_b
+		JOIN products pa ON pa.id = pairs.product_a
+		JOIN products pb ON pb.id = pairs.product_b
+		ORDER BY pairs.together DESC, pairs.product_a, pairs.product_b
+		LIMIT @limit`, map[string]interface{}{
+		"start": start, "end": end, "min": minTogether, "limit": limit,
+	}).Scan(&pairs).Error
+	return pairs, err
+}
+
+// AlsoBought returns active products bought together with productID in at least minTogether
+// baskets, and how many baskets contained productID itself.
+func (r *ReportRepository) AlsoBought(productID uint, start, end time.Time, minTogether int) (int, []models.AlsoBoughtProduct, error) {
+	if err := r.db.Select("id").First(&models.Product{}, productID).Error; err != nil {
+		return 0, nil, err
+	}
+
+	var productCount int64
+	err := r.db.Raw(`SELECT COUNT(DISTINCT d.transaction_id)
+		FROM transaction_details d
+		JOIN transactions t ON t.id = d.transaction_id
+		WHERE d.product_id = ? AND t.created_at >= ? AND t.created_at < ?`, productID, start, end).
+		Scan(&productCount).Error
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var products []models.AlsoBoughtProduct
+	err = r.db.Raw(`WITH `+basketsSQL+`
+		SELECT b.product_id, p.name, p.price, COUNT(*) AS together, MAX(c.cnt) AS count
+		FROM baskets a
+		JOIN baskets b ON b.transaction_id = a.transaction_id AND b.product_id <> a.product_id
+		JOIN item_counts c ON c.product_id = b.product_id
+		JOIN products p ON p.id = b.product_id AND p.deleted_at IS NULL
+		WHERE a.product_id = @product
+		GROUP BY b.product_id, p.name, p.price
+		HAVING COUNT(*) >= @min`, map[string]interface{}{
+		"start": start, "end": end, "min": minTogether, "product": productID,
+	}).Scan(&products).Error
+	return int(productCount), products, err
+}
+
+// DailyQuantity is the base-unit quantity of a product sold on one store-local day
+type DailyQuantity struct {
+	ProductID uint
+	Day       time.Time
+	Quantity  float64
+}
+
+// DailySales returns the quantity sold per product and store-local day in [start, end), optionally
+// limited to productIDs. Days without sales are not returned.
+func (r *ReportRepository) DailySales(timezone string, start, end time.Time, productIDs []uint) ([]DailyQuantity, error) {
+	query := r.db.Table("transaction_details d").
+		Select("d.product_id, (t.created_at AT TIME ZONE ?)::date AS day, SUM(d.quantity) AS quantity", timezone).
+		Joins("JOIN transactions t ON t.id = d.transaction_id").
+		Where("t.created_at >= ? AND t.created_at < ?", start, end)
+	if len(productIDs) > 0 {
+		query = query.Where("d.product_id IN ?", productIDs)
+	}
+
+	var rows []DailyQuantity
+	err := query.Group("d.product_id, day").Scan(&rows).Error
+	return rows, err
+}
+
+// ActiveProducts returns the active products to forecast, or only productIDs when given
+func (r *ReportRepository) ActiveProducts(productIDs []uint) ([]models.Product, error) {
+	query := r.db.Select("id", "name", "base_unit", "stock", "cost_price", "quantity_precision")
+	if len(productIDs) > 0 {
+		query = query.Where("id IN ?", productIDs)
+	}
+
+	var products []models.Product
+	err := query.Order("name, id").Find(&products).Error
+	return products, err
+}
+
+// ProductRevenue returns the revenue of every product in [start, end), highest first, for an ABC
+// analysis. Active products without sales are included, archived ones only when they sold.
+func (r *ReportRepository) ProductRevenue(start, end time.Time, categoryID *uint) ([]models.ABCProduct, error) {
+	query := `
+		SELECT p.id AS product_id, p.name, p.sku, p.category_id, p.stock, p.base_unit,
+			COALESCE(s.qty, 0) AS qty_sold, COALESCE(s.revenue, 0) AS revenue
+		FROM products p
+		LEFT JOIN (
+			SELECT d.product_id, SUM(d.quantity) AS qty, SUM(d.subtotal) AS revenue
+			FROM transaction_details d
+			JOIN transactions t ON t.id = d.transaction_id
+			WHERE t.created_at >= ? AND t.created_at < ?
+			GROUP BY d.product_id
+		) s ON s.product_id = p.id
+		WHERE (p.deleted_at IS NULL OR s.product_id IS NOT NULL)`
+	args := []interface{}{start, end}
+	if categoryID != nil {
+		if err := r.db.Select("id").First(&models.Category{}, *categoryID).Error; err != nil {
+			return nil, err
+		}
+		query += ` AND p.category_id IN (` + categorySubtreeSQL + `)`
+		args = append(args, *categoryID)
+	}
+	query += ` ORDER BY revenue DESC, p.id`
+
+	var products []models.ABCProduct
+	if err := r.db.Raw(query, args...).Scan(&products).Error; err != nil {
+		return nil, err
+	}
+
+	path, err := categoryChains(r.db)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		products[i].Category = categoryPath(path(products[i].CategoryID))
+	}
+
+	return products, nil
+}
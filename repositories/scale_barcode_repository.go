@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"Kasir-API/models"
+
+	"gorm.io/gorm"
+)
+
+type ScaleBarcodeRepository struct {
+	db *gorm.DB
+}
+
+func NewScaleBarcodeRepository(db *gorm.DB) *ScaleBarcodeRepository {
+	return &ScaleBarcodeRepository{db: db}
+}
+
+func (r *ScaleBarcodeRepository) GetAll(page models.Page) ([]models.ScaleBarcodeRule, models.PageInfo, error) {
+	var rules []models.ScaleBarcodeRule
+	info, err := paginate(r.db.Model(&models.ScaleBarcodeRule{}), page, listOrder{table: "scale_barcode_rules", terms: []string{"prefix", "id"}}, &rules)
+	return rules, info, err
+}
+
+func (r *ScaleBarcodeRepository) FindByID(id uint) (*models.ScaleBarcodeRule, error) {
+	var rule models.ScaleBarcodeRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *ScaleBarcodeRepository) FindByPrefix(prefix string) (*models.ScaleBarcodeRule, error) {
+	var rule models.ScaleBarcodeRule
+	if err := r.db.Where("prefix = ?", prefix).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *ScaleBarcodeRepository) Save(rule *models.ScaleBarcodeRule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r *ScaleBarcodeRepository) Delete(rule *models.ScaleBarcodeRule) error {
+	return r.db.Delete(rule).Error
+}
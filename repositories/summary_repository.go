@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"Kasir-API/config"
+	"Kasir-API/models"
+	"Kasir-API/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// summaryDay returns the store-local date a moment falls on, as stored in the summary tables
+func summaryDay(t time.Time) string {
+	return t.In(config.StoreLocation()).Format(utils.DateLayout)
+}
+
+// addToSummaries adds a transaction to the daily summaries, or removes it again with sign -1.
+// It runs inside the transaction that saves the sale so the summaries never drift.
+func addToSummaries(tx *gorm.DB, transaction *models.Transaction, sign int) error {
+	day, err := time.Parse(utils.DateLayout, summaryDay(transaction.CreatedAt))
+	if err != nil {
+		return err
+	}
+
+	daily := models.DailySalesSummary{
+		Day:          day,
+		Revenue:      sign * transaction.TotalAmount,
+		Transactions: sign,
+	}
+
+	products := make(map[uint]*models.DailyProductSummary)
+	var order []uint
+	for _, detail := range transaction.Details {
+		daily.ItemsSold += float64(sign) * detail.Quantity
+		daily.COGS += sign * detail.CostSubtotal
+
+		summary, ok := products[detail.ProductID]
+		if !ok {
+			summary = &models.DailyProductSummary{Day: day, ProductID: detail.ProductID, ProductName: detail.ProductName}
+			products[detail.ProductID] = summary
+			order = append(order, detail.ProductID)
+		}
+		summary.QtySold += float64(sign) * detail.Quantity
+		summary.Revenue += sign * detail.Subtotal
+		summary.COGS += sign * detail.CostSubtotal
+	}
+	daily.ItemsSold = utils.RoundTo(daily.ItemsSold, models.MaxQuantityPrecision)
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "day"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "revenue"}, Value: gorm.Expr("daily_sales_summaries.revenue + EXCLUDED.revenue")},
+			{Column: clause.Column{Name: "transactions"}, Value: gorm.Expr("daily_sales_summaries.transactions + EXCLUDED.transactions")},
+			{Column: clause.Column{Name: "items_sold"}, Value: gorm.Expr("daily_sales_summaries.items_sold + EXCLUDED.items_sold")},
+			{Column: clause.Column{Name: "cogs"}, Value: gorm.Expr("daily_sales_summaries.cogs + EXCLUDED.cogs")},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("EXCLUDED.updated_at")},
+		},
+	}).Create(&daily).Error; err != nil {
+		return err
+	}
+
+	for _, productID := range order {
+		summary := products[productID]
+		summary.QtySold = utils.RoundTo(summary.QtySold, models.MaxQuantityPrecision)
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "day"}, {Name: "product_id"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "product_name"}, Value: gorm.Expr("EXCLUDED.product_name")},
+				{Column: clause.Column{Name: "qty_sold"}, Value: gorm.Expr("daily_product_summaries.qty_sold + EXCLUDED.qty_sold")},
+				{Column: clause.Column{Name: "revenue"}, Value: gorm.Expr("daily_product_summaries.revenue + EXCLUDED.revenue")},
+				{Column: clause.Column{Name: "cogs"}, Value: gorm.Expr("daily_product_summaries.cogs + EXCLUDED.cogs")},
+				{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("EXCLUDED.updated_at")},
+			},
+		}).Create(summary).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type SummaryRepository struct {
+	db *gorm.DB
+}
+
+func NewSummaryRepository(db *gorm.DB) *SummaryRepository {
+	return &SummaryRepository{db: db}
+}
+
+// NeedsBackfill reports whether there are sales but the daily summaries were never filled
+func (r *SummaryRepository) NeedsBackfill() (bool, error) {
+	var summaries, transactions int64
+	if err := r.db.Model(&models.DailySalesSummary{}).Limit(1).Count(&summaries).Error; err != nil {
+		return false, err
+	}
+	if summaries > 0 {
+		return false, nil
+	}
+	if err := r.db.Model(&models.Transaction{}).Limit(1).Count(&transactions).Error; err != nil {
+		return false, err
+	}
+	return transactions > 0, nil
+}
+
+// FirstSaleAt returns when the first transaction was made, or nil without transactions
+func (r *SummaryRepository) FirstSaleAt() (*time.Time, error) {
+	var first *time.Time
+	err := r.db.Model(&models.Transaction{}).Select("MIN(created_at)").Row().Scan(&first)
+	return first, err
+}
+
+// Rebuild recomputes the summaries of the store-local days in [start, end) from the transactions
+// and returns how many days have sales. The summary tables are locked for the duration, checkouts
+// made meanwhile wait and are added on top of the rebuilt figures.
+func (r *SummaryRepository) Rebuild(start, end time.Time) (int, error) {
+	var days int64
+	location := config.StoreLocation().String()
+	startDay, endDay := summaryDay(start), summaryDay(end)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE daily_sales_summaries, daily_product_summaries IN EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("day >= ? AND day < ?", startDay, endDay).Delete(&models.DailySalesSummary{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("day >= ? AND day < ?", startDay, endDay).Delete(&models.DailyProductSummary{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Exec(`
+			INSERT INTO daily_sales_summaries (day, revenue, transactions, items_sold, cogs, updated_at)
+			SELECT (t.created_at AT TIME ZONE @tz)::date, SUM(t.total_amount), COUNT(t.id),
+				COALESCE(SUM(d.items), 0), COALESCE(SUM(d.cogs), 0), NOW()
+			FROM transactions t
+			LEFT JOIN (
+				SELECT transaction_id, SUM(quantity) AS items, SUM(cost_subtotal) AS cogs
+				FROM transaction_details
+				GROUP BY transaction_id
+			) d ON d.transaction_id = t.id
+			WHERE t.created_at >= @start AND t.created_at < @end
+			GROUP BY 1`, map[string]interface{}{"tz": location, "start": start, "end": end})
+		if result.Error != nil {
+			return result.Error
+		}
+		days = result.RowsAffected
+
+		return tx.Exec(`
+			INSERT INTO daily_product_summaries (day, product_id, product_name, qty_sold, revenue, cogs, updated_at)
+			SELECT (t.created_at AT TIME ZONE @tz)::date, d.product_id, MAX(d.product_name),
+				SUM(d.quantity), SUM(d.subtotal), SUM(d.cost_subtotal), NOW()
+			FROM transaction_details d
+			JOIN transactions t ON t.id = d.transaction_id
+			WHERE t.created_at >= @start AND t.created_at < @end
+			GROUP BY 1, 2`, map[string]interface{}{"tz": location, "start": start, "end": end}).Error
+	})
+
+	return int(days), err
+}
@@ -2,9 +2,13 @@ package repositories
 
 import (
 	"Kasir-API/models"
+	"Kasir-API/utils"
 	"fmt"
+	"math"
+	"sort"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransactionRepository struct {
@@ -17,16 +21,12 @@ func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
 
 func (r *TransactionRepository) Create(transaction *models.Transaction) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// 1. Save Transaction Header
-		if err := tx.Create(transaction).Error; err != nil {
-			return err
-		}
+		// 1. Lock each product, capture its cost and update stock
+		for i := range transaction.Details {
+			detail := &transaction.Details[i]
 
-		// 2. Process each detail (Save and Update Stock)
-		for _, detail := range transaction.Details {
-			// Update product stock
 			var product models.Product
-			if err := tx.First(&product, detail.ProductID).Error; err != nil {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, detail.ProductID).Error; err != nil {
 				return fmt.Errorf("product with ID %d not found", detail.ProductID)
 			}
 
@@ -34,31 +34,56 @@ func (r *TransactionRepository) Create(transaction *models.Transaction) error {
 				return fmt.Errorf("insufficient stock for product: %s", product.Name)
 			}
 
+			// Snapshot cost so later receipts don't change historical COGS
+			detail.CostPrice = product.CostPrice
+			detail.CostSubtotal = int(math.Round(product.CostPrice * detail.Quantity))
+
 			// Reduce stock
-			newStock := product.Stock - detail.Quantity
+			newStock := utils.RoundTo(product.Stock-detail.Quantity, models.MaxQuantityPrecision)
 			if err := tx.Model(&product).Update("stock", newStock).Error; err != nil {
 				return err
 			}
 		}
 
-		return nil
+		// 2. Save Transaction Header together with its details
+		if err := tx.Create(transaction).Error; err != nil {
+			return err
+		}
+
+		// 3. Keep the daily summaries in step with the new sale
+		return addToSummaries(tx, transaction, 1)
 	})
 }
 
-func (r *TransactionRepository) GetAll() ([]models.Transaction, error) {
+// GetAll returns one page of transactions, newest first
+func (r *TransactionRepository) GetAll(page models.Page) ([]models.Transaction, models.PageInfo, error) {
 	var transactions []models.Transaction
-	err := r.db.Preload("Details").Find(&transactions).Error
-	return transactions, err
+	order := listOrder{table: "transactions", terms: []string{"created_at", "id"}, desc: true}
+	info, err := paginate(r.db.Model(&models.Transaction{}), page, order, &transactions, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Details")
+	})
+	return transactions, info, err
 }
 
-func (r *TransactionRepository) GetReport(startDate, endDate string) (models.ReportResponse, error) {
-	var report models.ReportResponse
+// GetReport summarises sales in [query.Start, query.End). query.CategoryDepth rolls the category breakdown
+// up to that level of the tree (1 = top level), 0 keeps each product's own category.
+func (r *TransactionRepository) GetReport(query models.ReportQuery) (models.ReportResponse, error) {
+	report := models.ReportResponse{Start: query.Start, End: query.End, Source: models.ReportSourceLive}
+	if query.FromSummaries {
+		report.Source = models.ReportSourceSummary
+	}
 
 	// 1. Get Total Revenue and Total Transaksi
 	row := r.db.Model(&models.Transaction{}).
 		Select("SUM(total_amount) as total_revenue, COUNT(id) as total_transaksi").
-		Where("created_at >= ? AND created_at <= ?", startDate+" 00:00:00", endDate+" 23:59:59").
+		Where("created_at >= ? AND created_at < ?", query.Start, query.End).
 		Row()
+	if query.FromSummaries {
+		row = r.db.Model(&models.DailySalesSummary{}).
+			Select("SUM(revenue) as total_revenue, COALESCE(SUM(transactions), 0) as total_transaksi").
+			Where("day >= ? AND day < ?", summaryDay(query.Start), summaryDay(query.End)).
+			Row()
+	}
 
 	var totalRevenue *int
 	var totalTransaksi int
@@ -70,21 +95,170 @@ func (r *TransactionRepository) GetReport(startDate, endDate string) (models.Rep
 		report.TotalRevenue = *totalRevenue
 	}
 	report.TotalTransaksi = totalTransaksi
+	if totalTransaksi > 0 {
+		report.AverageBasket = math.Round(float64(report.TotalRevenue)/float64(totalTransaksi)*100) / 100
+	}
 
-	// 2. Get Produk Terlaris
-	var bestProduct models.BestSellingProduct
-	err := r.db.Model(&models.TransactionDetail{}).
-		Select("product_name as name, SUM(quantity) as qty_sold").
-		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
-		Where("transactions.created_at >= ? AND transactions.created_at <= ?", startDate+" 00:00:00", endDate+" 23:59:59").
-		Group("product_name").
-		Order("qty_sold DESC").
-		Limit(1).
-		Scan(&bestProduct).Error
+	// 2. Get COGS per product
+	var perProduct []models.ProductProfit
+	err := r.db.Table("(?) AS sales", r.productSales(query)).
+		Select("sales.product_id, MAX(sales.product_name) as name, SUM(sales.quantity) as qty_sold, SUM(sales.subtotal) as revenue, SUM(sales.cost_subtotal) as cogs").
+		Group("sales.product_id").
+		Order("revenue DESC").
+		Scan(&perProduct).Error
+	if err != nil {
+		return report, err
+	}
+
+	for i := range perProduct {
+		perProduct[i].GrossProfit = perProduct[i].Revenue - perProduct[i].COGS
+		perProduct[i].MarginPct = grossMarginPct(perProduct[i].Revenue, perProduct[i].COGS)
+		report.TotalCOGS += perProduct[i].COGS
+	}
+	report.PerProduct = perProduct
+
+	// 3. Rank best sellers by product, so products sharing a name stay apart
+	report.TopByQuantity = topSellers(perProduct, query.TopN, func(a, b models.ProductProfit) bool {
+		return a.QtySold > b.QtySold
+	})
+	report.TopByRevenue = topSellers(perProduct, query.TopN, func(a, b models.ProductProfit) bool {
+		return a.Revenue > b.Revenue
+	})
+	if len(report.TopByQuantity) > 0 {
+		best := report.TopByQuantity[0]
+		report.ProdukTerlaris = models.BestSellingProduct{ProductID: best.ProductID, Name: best.Name, QtySold: best.QtySold}
+	}
+
+	// 4. Get COGS per category
+	var perCategory []models.CategoryProfit
+	err = r.db.Table("(?) AS sales", r.productSales(query)).
+		Select("categories.id as category_id, categories.name as name, SUM(sales.quantity) as qty_sold, SUM(sales.subtotal) as revenue, SUM(sales.cost_subtotal) as cogs").
+		Joins("JOIN products ON products.id = sales.product_id").
+		Joins("JOIN categories ON categories.id = products.category_id").
+		Group("categories.id, categories.name").
+		Order("revenue DESC").
+		Scan(&perCategory).Error
+	if err != nil {
+		return report, err
+	}
 
-	if err == nil {
-		report.ProdukTerlaris = bestProduct
+	perCategory, err = r.rollUpCategories(perCategory, query.CategoryDepth)
+	if err != nil {
+		return report, err
+	}
+
+	for i := range perCategory {
+		perCategory[i].GrossProfit = perCategory[i].Revenue - perCategory[i].COGS
+		perCategory[i].MarginPct = grossMarginPct(perCategory[i].Revenue, perCategory[i].COGS)
 	}
+	report.PerCategory = perCategory
+
+	report.GrossProfit = report.TotalRevenue - report.TotalCOGS
+	report.GrossMarginPct = grossMarginPct(report.TotalRevenue, report.TotalCOGS)
 
 	return report, nil
 }
+
+// productSales selects the sold lines of the report range with product_id, product_name, quantity,
+// subtotal and cost_subtotal columns, either from the transactions or from the daily summaries
+func (r *TransactionRepository) productSales(query models.ReportQuery) *gorm.DB {
+	if query.FromSummaries {
+		return r.db.Model(&models.DailyProductSummary{}).
+			Select("product_id, product_name, qty_sold AS quantity, revenue AS subtotal, cogs AS cost_subtotal").
+			Where("day >= ? AND day < ?", summaryDay(query.Start), summaryDay(query.End))
+	}
+
+	return r.db.Model(&models.TransactionDetail{}).
+		Select("transaction_details.product_id, transaction_details.product_name, transaction_details.quantity, transaction_details.subtotal, transaction_details.cost_subtotal").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Where("transactions.created_at >= ? AND transactions.created_at < ?", query.Start, query.End)
+}
+
+// rollUpCategories fills each row's path and merges rows into their ancestor at the given depth
+func (r *TransactionRepository) rollUpCategories(rows []models.CategoryProfit, depth int) ([]models.CategoryProfit, error) {
+	path, err := categoryChains(r.db)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[uint]*models.CategoryProfit)
+	var order []uint
+	for _, row := range rows {
+		chain := path(row.CategoryID)
+		if depth > 0 && len(chain) > depth {
+			chain = chain[:depth]
+		}
+
+		target := row.CategoryID
+		if len(chain) > 0 {
+			target = chain[len(chain)-1].ID
+		}
+
+		existing, ok := merged[target]
+		if !ok {
+			existing = &models.CategoryProfit{
+				CategoryID: target,
+				Name:       row.Name,
+				Path:       categoryPath(chain),
+			}
+			if len(chain) > 0 {
+				existing.Name = chain[len(chain)-1].Name
+			}
+			merged[target] = existing
+			order = append(order, target)
+		}
+
+		existing.QtySold += row.QtySold
+		existing.Revenue += row.Revenue
+		existing.COGS += row.COGS
+	}
+
+	result := make([]models.CategoryProfit, 0, len(order))
+	for _, id := range order {
+		result = append(result, *merged[id])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Revenue > result[j].Revenue
+	})
+
+	return result, nil
+}
+
+// topSellers ranks products with better-first and keeps the first n, ties go to the lower product ID
+func topSellers(rows []models.ProductProfit, n int, better func(a, b models.ProductProfit) bool) []models.TopSeller {
+	ranked := make([]models.ProductProfit, len(rows))
+	copy(ranked, rows)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if better(ranked[i], ranked[j]) {
+			return true
+		}
+		if better(ranked[j], ranked[i]) {
+			return false
+		}
+		return ranked[i].ProductID < ranked[j].ProductID
+	})
+
+	if n > 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+
+	result := make([]models.TopSeller, len(ranked))
+	for i, row := range ranked {
+		result[i] = models.TopSeller{
+			Rank:      i + 1,
+			ProductID: row.ProductID,
+			Name:      row.Name,
+			QtySold:   row.QtySold,
+			Revenue:   row.Revenue,
+		}
+	}
+	return result
+}
+
+// grossMarginPct returns gross profit as a percentage of revenue, rounded to 2 decimals
+func grossMarginPct(revenue, cogs int) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(float64(revenue-cogs)/float64(revenue)*10000) / 100
+}
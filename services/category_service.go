@@ -0,0 +1,170 @@
+package services
+
+import (
+	"Kasir-API/models"
+	"Kasir-API/repositories"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type CategoryService struct {
+	repo *repositories.CategoryRepository
+}
+
+func NewCategoryService(repo *repositories.CategoryRepository) *CategoryService {
+	return &CategoryService{repo: repo}
+}
+
+// GetAll returns one page of active categories, or only archived ones when archived is true
+func (s *CategoryService) GetAll(archived bool, page models.Page) ([]models.Category, models.PageInfo, error) {
+	return s.repo.List(archived, page)
+}
+
+// GetByID returns a category whether or not it is archived
+func (s *CategoryService) GetByID(id uint) (*models.Category, error) {
+	return s.repo.FindAnyByID(id)
+}
+
+// FindActive returns a category that is not archived
+func (s *CategoryService) FindActive(id uint) (*models.Category, error) {
+	return s.repo.FindByID(id)
+}
+
+// FindByName returns the category, active or archived, that owns a name
+func (s *CategoryService) FindByName(name string) (*models.Category, error) {
+	return s.repo.FindByName(name)
+}
+
+// GetTree returns active categories nested under their parents
+func (s *CategoryService) GetTree() ([]*models.Category, error) {
+	categories, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*models.Category, len(categories))
+	for i := range categories {
+		nodes[categories[i].ID] = &categories[i]
+	}
+
+	roots := []*models.Category{}
+	for i := range categories {
+		category := &categories[i]
+		// Children of an archived parent are shown at the top level
+		if parent, ok := nodes[parentID(category)]; ok {
+			parent.Children = append(parent.Children, category)
+		} else {
+			roots = append(roots, category)
+		}
+	}
+
+	return roots, nil
+}
+
+// validateParent checks that parentID exists and is not the category itself or one of its descendants
+func validateParent(repo *repositories.CategoryRepository, categoryID, parentID uint) error {
+	if _, err := repo.FindByID(parentID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidParent
+		}
+		return err
+	}
+
+	if categoryID == 0 {
+		return nil
+	}
+
+	ancestors, err := repo.AncestorIDs(parentID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ancestors {
+		if id == categoryID {
+			return ErrCategoryCycle
+		}
+	}
+
+	return nil
+}
+
+// Create saves a new category, checking its parent under the tree lock
+func (s *CategoryService) Create(category *models.Category) error {
+	return s.repo.WithTreeLock(func(repo *repositories.CategoryRepository) error {
+		if category.ParentID != nil {
+			if err := validateParent(repo, 0, *category.ParentID); err != nil {
+				return err
+			}
+		}
+		return repo.Create(category)
+	})
+}
+
+// Save updates a category. A new parent is checked under the tree lock, so two categories moved
+// under each other at the same time cannot both pass the check.
+func (s *CategoryService) Save(category *models.Category) error {
+	return s.repo.WithTreeLock(func(repo *repositories.CategoryRepository) error {
+		stored, err := repo.FindAnyByID(category.ID)
+		if err != nil {
+			return err
+		}
+		if category.ParentID != nil && *category.ParentID != parentID(stored) {
+			if err := validateParent(repo, category.ID, *category.ParentID); err != nil {
+				return err
+			}
+		}
+		return repo.Save(category)
+	})
+}
+
+// Restore brings back an archived category
+func (s *CategoryService) Restore(id uint) (*models.Category, error) {
+	return s.repo.Restore(id)
+}
+
+// Delete archives (or permanently deletes) a category. Products and subcategories must be
+// moved to targetID first; without a target a category that is still in use is refused.
+// Archived products and subcategories only stand in the way of a permanent delete.
+func (s *CategoryService) Delete(id uint, targetID *uint, permanent bool) (*models.CategoryDeleteResult, error) {
+	var result *models.CategoryDeleteResult
+	err := s.repo.WithTreeLock(func(repo *repositories.CategoryRepository) error {
+		category, err := repo.FindAnyByID(id)
+		if err != nil {
+			return err
+		}
+
+		if targetID != nil {
+			if *targetID == id {
+				return ErrInvalidTarget
+			}
+			// The target must not sit below the deleted category, otherwise moving its children creates a cycle
+			if err := validateParent(repo, id, *targetID); err != nil {
+				if errors.Is(err, ErrInvalidParent) || errors.Is(err, ErrCategoryCycle) {
+					return ErrInvalidTarget
+				}
+				return err
+			}
+		}
+
+		result, err = repo.Delete(category, targetID, permanent, func(products, children int64) error {
+			if products > 0 || children > 0 {
+				return &CategoryInUseError{Products: products, Children: children}
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func parentID(category *models.Category) uint {
+	if category.ParentID == nil {
+		return 0
+	}
+	return *category.ParentID
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnknownUnit           = errors.New("unit is not defined for this product")
+	ErrDuplicateUnit         = errors.New("unit already exists for this product")
+	ErrInvalidQuantity       = errors.New("quantity exceeds the product's decimal precision")
+	ErrDuplicatePrefix       = errors.New("a scale barcode rule with this prefix already exists")
+	ErrInvalidScaleRule      = errors.New("invalid scale barcode rule")
+	ErrInvalidBarcode        = errors.New("invalid barcode")
+	ErrInvalidParent         = errors.New("parent category not found")
+	ErrCategoryCycle         = errors.New("category cannot be moved under itself or one of its descendants")
+	ErrPriceAlreadyEffective = errors.New("only scheduled prices that have not taken effect can be cancelled")
+	ErrInvalidTarget         = errors.New("target category must be an active category outside the deleted category's subtree")
+	ErrInvalidInterval       = errors.New("interval must be one of hour, day, week or month")
+	ErrRangeTooLarge         = errors.New("date range has too many buckets for this interval")
+	ErrNothingToReorder      = errors.New("no product needs to be reordered")
+	ErrInvalidImport         = errors.New("invalid import file")
+	ErrInvalidComparison     = errors.New("compare must be previous, last_year or custom with compare_start_date and compare_end_date")
+	ErrInvalidABCThresholds  = errors.New("ABC thresholds must satisfy 0 < threshold_a < threshold_b <= 100")
+)
+
+// CategoryInUseError is returned when deleting a category that still has products or subcategories
+type CategoryInUseError struct {
+	Products int64
+	Children int64
+}
+
+func (e *CategoryInUseError) Error() string {
+	var blockers []string
+	if e.Products > 0 {
+		blockers = append(blockers, countNoun(e.Products, "product", "products"))
+	}
+	if e.Children > 0 {
+		blockers = append(blockers, countNoun(e.Children, "subcategory", "subcategories"))
+	}
+	return fmt.Sprintf("category still has %s, provide target_category_id to move them", strings.Join(blockers, " and "))
+}
+
+func countNoun(count int64, singular, plural string) string {
+	if count == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
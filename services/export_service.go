@@ -0,0 +1,31 @@
+package services
+
+import (
+	"Kasir-API/models"
+	"Kasir-API/repositories"
+	"time"
+)
+
+type ExportService struct {
+	repo         *repositories.ExportRepository
+	transactions *TransactionService
+}
+
+func NewExportService(repo *repositories.ExportRepository, transactions *TransactionService) *ExportService {
+	return &ExportService{repo: repo, transactions: transactions}
+}
+
+// EachTransactionLine calls fn for every line of the transactions made in [start, end)
+func (s *ExportService) EachTransactionLine(start, end time.Time, fn func(line *models.TransactionExportLine) error) error {
+	return s.repo.EachTransactionLine(start, end, fn)
+}
+
+// EachProduct calls fn for every active product
+func (s *ExportService) EachProduct(fn func(product *models.ProductExportRow) error) error {
+	return s.repo.EachProduct(fn)
+}
+
+// SalesReport returns the sales report of [start, end), broken down per product and category
+func (s *ExportService) SalesReport(start, end time.Time, categoryDepth int) (models.ReportResponse, error) {
+	return s.transactions.GetReport(models.ReportQuery{Start: start, End: end, CategoryDepth: categoryDepth})
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"Kasir-API/config"
+	"Kasir-API/models"
+	"Kasir-API/repositories"
+	"Kasir-API/utils"
+	"math"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	smoothingAlpha = 0.3  // Weight of the newest day in the exponential smoothing
+	safetyFactor   = 1.65 // About a 95% chance of not running out during the lead time
+)
+
+type ForecastService struct {
+	reports *repositories.ReportRepository
+	lists   *repositories.PurchaseListRepository
+}
+
+func NewForecastService(reports *repositories.ReportRepository, lists *repositories.PurchaseListRepository) *ForecastService {
+	return &ForecastService{reports: reports, lists: lists}
+}
+
+// Forecast predicts demand per product from the past daily sales and suggests how much to reorder
+// so stock covers the lead time plus cover days. Today is left out of the history as it is incomplete.
+func (s *ForecastService) Forecast(request models.ForecastRequest) (*models.ForecastReport, error) {
+	report := &models.ForecastReport{
+		GeneratedAt:  time.Now(),
+		HistoryDays:  request.HistoryDays,
+		LeadTimeDays: viper.GetInt("REORDER_LEAD_TIME_DAYS"),
+		CoverDays:    viper.GetInt("REORDER_COVER_DAYS"),
+	}
+	if report.HistoryDays == 0 {
+		report.HistoryDays = viper.GetInt("FORECAST_HISTORY_DAYS")
+	}
+	if request.LeadTimeDays != nil {
+		report.LeadTimeDays = *request.LeadTimeDays
+	}
+	if request.CoverDays != nil {
+		report.CoverDays = *request.CoverDays
+	}
+
+	location := config.StoreLocation()
+	now := report.GeneratedAt.In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	start := today.AddDate(0, 0, -report.HistoryDays)
+
+	products, err := s.reports.ActiveProducts(request.ProductIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.reports.DailySales(location.String(), start, today, request.ProductIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Zero-filled daily series per product, index 0 is the first day of the history
+	series := make(map[uint][]float64, len(products))
+	for _, row := range rows {
+		day := time.Date(row.Day.Year(), row.Day.Month(), row.Day.Day(), 0, 0, 0, 0, location)
+		offset := int(math.Round(day.Sub(start).Hours() / 24))
+		if offset < 0 || offset >= report.HistoryDays {
+			continue
+		}
+		if _, ok := series[row.ProductID]; !ok {
+			series[row.ProductID] = make([]float64, report.HistoryDays)
+		}
+		series[row.ProductID][offset] = row.Quantity
+	}
+
+	report.Products = make([]models.ProductForecast, 0, len(products))
+	for _, product := range products {
+		history, ok := series[product.ID]
+		if !ok {
+			history = make([]float64, report.HistoryDays)
+		}
+		report.Products = append(report.Products, forecastProduct(product, history, start, today, report.LeadTimeDays, report.CoverDays))
+	}
+
+	return report, nil
+}
+
+// forecastProduct runs exponential smoothing on the weekday-adjusted history and turns the
+// forecast into a reorder point and suggested order quantity
+func forecastProduct(product models.Product, history []float64, start, today time.Time, leadTime, cover int) models.ProductForecast {
+	forecast := models.ProductForecast{
+		ProductID: product.ID,
+		Name:      product.Name,
+		BaseUnit:  product.BaseUnit,
+		Stock:     product.Stock,
+		UnitCost:  product.CostPrice,
+	}
+	for i := range forecast.WeekdayIndex {
+		forecast.WeekdayIndex[i] = 1
+	}
+
+	mean, deviation := meanAndDeviation(history)
+	if mean == 0 {
+		return forecast
+	}
+
+	// Weekday seasonality: how a weekday's average compares to the overall average
+	var weekdayTotal [7]float64
+	var weekdayDays [7]int
+	for i, quantity := range history {
+		weekday := start.AddDate(0, 0, i).Weekday()
+		weekdayTotal[weekday] += quantity
+		weekdayDays[weekday]++
+	}
+	for weekday := range forecast.WeekdayIndex {
+		if weekdayDays[weekday] > 0 {
+			forecast.WeekdayIndex[weekday] = utils.RoundTo(weekdayTotal[weekday]/float64(weekdayDays[weekday])/mean, 3)
+		}
+	}
+
+	level := mean
+	for i, quantity := range history {
+		index := forecast.WeekdayIndex[start.AddDate(0, 0, i).Weekday()]
+		if index == 0 {
+			continue // This weekday never sells, it says nothing about the level
+		}
+		level = smoothingAlpha*(quantity/index) + (1-smoothingAlpha)*level
+	}
+
+	for day := 0; day < leadTime+cover; day++ {
+		demand := level * forecast.WeekdayIndex[today.AddDate(0, 0, day).Weekday()]
+		if day < leadTime {
+			forecast.DemandLeadTime += demand
+		}
+		forecast.DemandHorizon += demand
+	}
+
+	forecast.SafetyStock = safetyFactor * deviation * math.Sqrt(math.Max(float64(leadTime), 1))
+	forecast.ReorderPoint = forecast.DemandLeadTime + forecast.SafetyStock
+
+	if product.Stock <= forecast.ReorderPoint {
+		needed := forecast.DemandHorizon + forecast.SafetyStock - product.Stock
+		scale := math.Pow(10, float64(product.QuantityPrecision))
+		forecast.SuggestedQuantity = math.Max(math.Ceil(needed*scale-1e-9)/scale, 0)
+	}
+
+	forecast.AvgDailyDemand = utils.RoundTo(level, models.MaxQuantityPrecision)
+	forecast.DemandLeadTime = utils.RoundTo(forecast.DemandLeadTime, models.MaxQuantityPrecision)
+	forecast.DemandHorizon = utils.RoundTo(forecast.DemandHorizon, models.MaxQuantityPrecision)
+	forecast.SafetyStock = utils.RoundTo(forecast.SafetyStock, models.MaxQuantityPrecision)
+	forecast.ReorderPoint = utils.RoundTo(forecast.ReorderPoint, models.MaxQuantityPrecision)
+
+	return forecast
+}
+
+func meanAndDeviation(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+// CreatePurchaseList turns the current reorder suggestions into a draft purchase list
+func (s *ForecastService) CreatePurchaseList(request models.PurchaseListFromForecastRequest) (*models.PurchaseList, error) {
+	report, err := s.Forecast(request.ForecastRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	list := models.PurchaseList{
+		Status: models.PurchaseListDraft,
+		Note:   request.Note,
+	}
+	for _, forecast := range report.Products {
+		if forecast.SuggestedQuantity <= 0 {
+			continue
+		}
+		subtotal := roundMoney(forecast.SuggestedQuantity * forecast.UnitCost)
+		list.Items = append(list.Items, models.PurchaseListItem{
+			ProductID:      forecast.ProductID,
+			ProductName:    forecast.Name,
+			Quantity:       forecast.SuggestedQuantity,
+			BaseUnit:       forecast.BaseUnit,
+			UnitCost:       forecast.UnitCost,
+			Subtotal:       subtotal,
+			StockAtCreate:  forecast.Stock,
+			ForecastDemand: forecast.DemandHorizon,
+		})
+		list.TotalCost += subtotal
+	}
+
+	if len(list.Items) == 0 {
+		return nil, ErrNothingToReorder
+	}
+	list.TotalCost = roundMoney(list.TotalCost)
+
+	if err := s.lists.Create(&list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (s *ForecastService) GetPurchaseLists(page models.Page) ([]models.PurchaseList, models.PageInfo, error) {
+	return s.lists.GetAll(page)
+}
+
+func (s *ForecastService) GetPurchaseList(id uint) (*models.PurchaseList, error) {
+	return s.lists.FindByID(id)
+}
+
+// DeletePurchaseList discards a purchase list. Lists are only ever drafts, nothing is ordered from them yet.
+func (s *ForecastService) DeletePurchaseList(id uint) error {
+	list, err := s.lists.FindByID(id)
+	if err != nil {
+		return err
+	}
+	return s.lists.Delete(list)
+}
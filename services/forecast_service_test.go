@@ -0,0 +1,116 @@
+package services
+
+import (
+	"Kasir-API/models"
+	"testing"
+	"time"
+)
+
+func TestForecastProduct(t *testing.T) {
+	// Two full weeks starting on a Monday, so every weekday appears twice
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	today := start.AddDate(0, 0, 14)
+
+	repeat := func(days int, quantity float64) []float64 {
+		history := make([]float64, days)
+		for i := range history {
+			history[i] = quantity
+		}
+		return history
+	}
+	// Sells 7 on both Saturdays and nothing on other days
+	saturdays := make([]float64, 14)
+	saturdays[5], saturdays[12] = 7, 7
+	// A quiet week followed by a busy one, each weekday averages 2
+	rising := append(repeat(7, 1), repeat(7, 3)...)
+
+	flat := [7]float64{1, 1, 1, 1, 1, 1, 1}
+
+	tests := []struct {
+		name      string
+		product   models.Product
+		history   []float64
+		leadTime  int
+		cover     int
+		want      models.ProductForecast
+		wantIndex [7]float64
+	}{
+		{
+			name:      "no sales",
+			product:   models.Product{Stock: 4},
+			history:   repeat(14, 0),
+			leadTime:  3,
+			cover:     4,
+			want:      models.ProductForecast{Stock: 4},
+			wantIndex: flat,
+		},
+		{
+			name:      "steady demand below the reorder point",
+			product:   models.Product{Stock: 5},
+			history:   repeat(14, 2),
+			leadTime:  3,
+			cover:     4,
+			want:      models.ProductForecast{Stock: 5, AvgDailyDemand: 2, DemandLeadTime: 6, DemandHorizon: 14, ReorderPoint: 6, SuggestedQuantity: 9},
+			wantIndex: flat,
+		},
+		{
+			name:      "steady demand above the reorder point",
+			product:   models.Product{Stock: 10},
+			history:   repeat(14, 2),
+			leadTime:  3,
+			cover:     4,
+			want:      models.ProductForecast{Stock: 10, AvgDailyDemand: 2, DemandLeadTime: 6, DemandHorizon: 14, ReorderPoint: 6},
+			wantIndex: flat,
+		},
+		{
+			// Deviation is sqrt(6), so safety stock is 1.65 * sqrt(6) * sqrt(7) = 10.693
+			name:      "weekday index and safety stock, ceiled to whole pieces",
+			product:   models.Product{},
+			history:   saturdays,
+			leadTime:  7,
+			want:      models.ProductForecast{AvgDailyDemand: 1, DemandLeadTime: 7, DemandHorizon: 7, SafetyStock: 10.693, ReorderPoint: 17.693, SuggestedQuantity: 18},
+			wantIndex: [7]float64{0, 0, 0, 0, 0, 0, 7},
+		},
+		{
+			name:      "suggestion ceiled to the quantity precision",
+			product:   models.Product{QuantityPrecision: 2},
+			history:   saturdays,
+			leadTime:  7,
+			want:      models.ProductForecast{AvgDailyDemand: 1, DemandLeadTime: 7, DemandHorizon: 7, SafetyStock: 10.693, ReorderPoint: 17.693, SuggestedQuantity: 17.7},
+			wantIndex: [7]float64{0, 0, 0, 0, 0, 0, 7},
+		},
+		{
+			// Smoothing from the mean of 2 towards 1 for a week and then towards 3:
+			// 3 - (3 - (1 + 0.7^7)) * 0.7^7 = 2.842
+			name:      "smoothing follows the recent level",
+			product:   models.Product{Stock: 100},
+			history:   rising,
+			leadTime:  1,
+			cover:     1,
+			want:      models.ProductForecast{Stock: 100, AvgDailyDemand: 2.842, DemandLeadTime: 2.842, DemandHorizon: 5.684, SafetyStock: 1.65, ReorderPoint: 4.492},
+			wantIndex: flat,
+		},
+		{
+			// Without lead time the safety stock still covers one day
+			name:      "zero lead time",
+			product:   models.Product{},
+			history:   saturdays,
+			cover:     7,
+			want:      models.ProductForecast{AvgDailyDemand: 1, DemandHorizon: 7, SafetyStock: 4.042, ReorderPoint: 4.042, SuggestedQuantity: 12},
+			wantIndex: [7]float64{0, 0, 0, 0, 0, 0, 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := forecastProduct(tt.product, tt.history, start, today, tt.leadTime, tt.cover)
+			if got.WeekdayIndex != tt.wantIndex {
+				t.Errorf("WeekdayIndex = %v, want %v", got.WeekdayIndex, tt.wantIndex)
+			}
+			tt.want.WeekdayIndex = tt.wantIndex
+			if got != tt.want {
+				t.Errorf("forecastProduct() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
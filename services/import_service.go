@@ -0,0 +1,256 @@
+package services
+
+import (
+	"Kasir-API/models"
+	"Kasir-API/repositories"
+	"Kasir-API/utils"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// importColumnAliases are the headers recognised per field, compared lower-case with "_" as space.
+// They include the headers of GET /export/products so an export can be edited and imported again.
+var importColumnAliases = map[string][]string{
+	"name":               {"name", "nama", "nama produk", "product name", "produk", "product"},
+	"sku":                {"sku", "kode", "kode produk"},
+	"plu":                {"plu"},
+	"barcodes":           {"barcodes", "barcode"},
+	"category":           {"category", "kategori"},
+	"price":              {"price", "harga", "harga jual", "selling price"},
+	"cost_price":         {"cost price", "harga pokok", "hpp", "cost"},
+	"stock":              {"stock", "stok"},
+	"base_unit":          {"base unit", "satuan", "satuan dasar", "unit"},
+	"measure_type":       {"measure type", "jenis ukuran"},
+	"quantity_precision": {"quantity precision", "presisi jumlah"},
+}
+
+type ImportService struct {
+	repo *repositories.ImportRepository
+}
+
+func NewImportService(repo *repositories.ImportRepository) *ImportService {
+	return &ImportService{repo: repo}
+}
+
+// ImportProducts creates and updates products from a table whose first row holds the headers.
+// mapping overrides the recognised headers with field -> header. The products are only written
+// when dryRun is false and every row is valid.
+func (s *ImportService) ImportProducts(table *utils.Table, mapping map[string]string, dryRun bool) (*models.ProductImportResult, error) {
+	if len(table.Rows) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+
+	columns, names, err := mapImportColumns(table.Rows[0], mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.ProductImportRow
+	for i := 1; i < len(table.Rows); i++ {
+		record := table.Rows[i]
+		if isBlankRecord(record) {
+			continue
+		}
+		index := i
+		isNumber := func(column int) bool { return table.IsNumber(index, column) }
+		rows = append(rows, parseImportRow(i+1, record, columns, isNumber))
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the file has no product rows", ErrInvalidImport)
+	}
+	if len(rows) > models.MaxImportRows {
+		return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidImport, models.MaxImportRows)
+	}
+
+	results, categoriesCreated, err := s.repo.ImportProducts(rows, !dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ProductImportResult{
+		DryRun:            dryRun,
+		Columns:           names,
+		TotalRows:         len(rows),
+		CategoriesCreated: categoriesCreated,
+		Rows:              results,
+	}
+	if result.CategoriesCreated == nil {
+		result.CategoriesCreated = []string{}
+	}
+	for _, row := range results {
+		switch {
+		case len(row.Errors) > 0:
+			result.InvalidRows++
+		case row.Action == models.ImportActionCreate:
+			result.Created++
+		case row.Action == models.ImportActionUpdate:
+			result.Updated++
+		}
+	}
+	result.Applied = !dryRun && result.InvalidRows == 0
+
+	return result, nil
+}
+
+// mapImportColumns returns the column index per field and the header each field was read from
+func mapImportColumns(header []string, mapping map[string]string) (map[string]int, map[string]string, error) {
+	normalize := func(text string) string {
+		return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(text, "_", " "))), " ")
+	}
+
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		key := normalize(name)
+		if _, ok := positions[key]; !ok && key != "" {
+			positions[key] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	names := make(map[string]string)
+	for field, column := range mapping {
+		if _, ok := importColumnAliases[field]; !ok {
+			return nil, nil, fmt.Errorf("%w: unknown field %q in mapping, use one of %s", ErrInvalidImport, field, strings.Join(models.ProductImportFields, ", "))
+		}
+		position, ok := positions[normalize(column)]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: column %q mapped to %s is not in the file", ErrInvalidImport, column, field)
+		}
+		columns[field] = position
+		names[field] = header[position]
+	}
+
+	for _, field := range models.ProductImportFields {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+		for _, alias := range importColumnAliases[field] {
+			if position, ok := positions[alias]; ok {
+				columns[field] = position
+				names[field] = header[position]
+				break
+			}
+		}
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, nil, fmt.Errorf("%w: no name column found, add one or map it", ErrInvalidImport)
+	}
+	return columns, names, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseImportRow reads and validates the fields of one record, collecting every problem it finds.
+// isNumber tells which cells a spreadsheet stored as numbers, those are read without guessing separators.
+func parseImportRow(line int, record []string, columns map[string]int, isNumber func(column int) bool) models.ProductImportRow {
+	row := models.ProductImportRow{Row: line}
+	fail := func(format string, args ...interface{}) {
+		row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+	}
+
+	value := func(field string) (string, bool) {
+		position, ok := columns[field]
+		if !ok {
+			return "", false
+		}
+		if position >= len(record) {
+			return "", true
+		}
+		return strings.TrimSpace(record[position]), true
+	}
+
+	// number returns nil for an empty cell so existing products keep their value
+	number := func(field string, places int, parse func(string) (float64, error)) *float64 {
+		text, _ := value(field)
+		if text == "" {
+			return nil
+		}
+		if isNumber(columns[field]) {
+			parse = func(text string) (float64, error) { return strconv.ParseFloat(text, 64) }
+		}
+		parsed, err := parse(text)
+		if err != nil {
+			fail("%s %q is not a number", field, text)
+			return nil
+		}
+		if parsed < 0 {
+			fail("%s must not be negative", field)
+			return nil
+		}
+		parsed = utils.RoundTo(parsed, places)
+		return &parsed
+	}
+
+	row.Name, _ = value("name")
+	if length := len([]rune(row.Name)); length < 3 || length > 100 {
+		fail("name must be between 3 and 100 characters")
+	}
+
+	row.SKU, _ = value("sku")
+	if len(row.SKU) > 64 {
+		fail("sku must be at most 64 characters")
+	}
+
+	if plu, _ := value("plu"); plu != "" {
+		if _, err := strconv.ParseUint(plu, 10, 64); err != nil || len(plu) > 6 {
+			fail("plu must be at most 6 digits")
+		}
+		if plu = strings.TrimLeft(plu, "0"); plu != "" {
+			row.PLU = &plu
+		}
+	}
+
+	if text, _ := value("barcodes"); text != "" {
+		codes := []string{}
+		for _, code := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+			if err := utils.ValidateBarcode(code); err != nil {
+				fail("%s", err.Error())
+			}
+			codes = append(codes, code)
+		}
+		row.Barcodes = &codes
+	}
+
+	row.Category, _ = value("category")
+
+	// Prices are read as amounts so "15.000" is fifteen thousand Rupiah, not 15
+	row.Price = number("price", 2, utils.ParseAmount)
+	if row.Price != nil && *row.Price == 0 {
+		fail("price must be greater than 0")
+	}
+	row.CostPrice = number("cost_price", 2, utils.ParseAmount)
+	row.Stock = number("stock", models.MaxQuantityPrecision, utils.ParseDecimal)
+
+	row.BaseUnit, _ = value("base_unit")
+	if len(row.BaseUnit) > 20 {
+		fail("base_unit must be at most 20 characters")
+	}
+
+	row.MeasureType, _ = value("measure_type")
+	row.MeasureType = strings.ToLower(row.MeasureType)
+	switch row.MeasureType {
+	case "", models.MeasureCount, models.MeasureWeight, models.MeasureMeasure:
+	default:
+		fail("measure_type must be count, weight or measure")
+	}
+
+	if text, _ := value("quantity_precision"); text != "" {
+		precision, err := strconv.Atoi(text)
+		if err != nil || precision < 0 || precision > models.MaxQuantityPrecision {
+			fail("quantity_precision must be a whole number between 0 and %d", models.MaxQuantityPrecision)
+		} else {
+			row.QuantityPrecision = &precision
+		}
+	}
+
+	return row
+}
@@ -3,16 +3,243 @@ package services
 import (
 	"Kasir-API/models"
 	"Kasir-API/repositories"
+	"Kasir-API/utils"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
 )
 
 type ProductService struct {
-	repo *repositories.ProductRepository
+	repo  *repositories.ProductRepository
+	scale *ScaleBarcodeService
+}
+
+func NewProductService(repo *repositories.ProductRepository, scale *ScaleBarcodeService) *ProductService {
+	return &ProductService{repo: repo, scale: scale}
+}
+
+func (s *ProductService) GetAll(filter models.ProductFilter, page models.Page) ([]models.Product, models.PageInfo, error) {
+	if filter.LowStock && filter.LowStockThreshold == nil {
+		threshold := viper.GetFloat64("LOW_STOCK_THRESHOLD")
+		filter.LowStockThreshold = &threshold
+	}
+	return s.repo.GetAll(filter, page)
+}
+
+// GetByID returns a product priced at the moment of the call
+func (s *ProductService) GetByID(id uint) (*models.Product, error) {
+	product, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return product, s.applyEffectivePrice(product)
+}
+
+// applyEffectivePrice makes sure a scheduled price that is due but not yet applied by the scheduler is used
+func (s *ProductService) applyEffectivePrice(product *models.Product) error {
+	price, err := s.repo.EffectivePrice(product.ID, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	product.Price = price.Price
+	return nil
+}
+
+func (s *ProductService) GetPrices(productID uint, page models.Page) ([]models.ProductPrice, models.PageInfo, error) {
+	if _, err := s.repo.FindByID(productID); err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	return s.repo.GetPrices(productID, page)
+}
+
+// SchedulePrice records a price change, applying it right away when it is not in the future
+func (s *ProductService) SchedulePrice(productID uint, request models.ProductPriceRequest) (*models.ProductPrice, error) {
+	if _, err := s.repo.FindByID(productID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := models.ProductPrice{
+		ProductID:     productID,
+		Price:         request.Price,
+		EffectiveFrom: now,
+	}
+	if request.EffectiveFrom != nil {
+		entry.EffectiveFrom = *request.EffectiveFrom
+	}
+
+	if err := s.repo.AddPrice(&entry, now); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// CancelPrice removes a scheduled price change that has not taken effect yet
+func (s *ProductService) CancelPrice(productID, priceID uint) error {
+	price, err := s.repo.FindPrice(productID, priceID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if price.Applied || !price.EffectiveFrom.After(now) {
+		return ErrPriceAlreadyEffective
+	}
+
+	return s.repo.DeletePrice(price, now)
 }
 
-func NewProductService(repo *repositories.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+// RunPriceScheduler applies due scheduled prices now and then on every tick
+func (s *ProductService) RunPriceScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		updated, err := s.repo.ApplyDuePrices(time.Now())
+		if err != nil {
+			log.Printf("⚠️ Failed to apply scheduled prices: %v", err)
+		} else if updated > 0 {
+			log.Printf("💲 Applied scheduled prices to %d products", updated)
+		}
+
+		<-ticker.C
+	}
+}
+
+// Scan resolves a scanned code. Scale-printed codes resolve by PLU and carry the embedded weight or price,
+// any other code is looked up as a regular barcode or SKU.
+func (s *ProductService) Scan(code string) (*models.ScanResult, error) {
+	reading, err := s.scale.Decode(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBarcode, err)
+	}
+
+	if reading == nil {
+		product, err := s.repo.FindByBarcode(code)
+		if err != nil {
+			return nil, err
+		}
+		return &models.ScanResult{Product: product}, s.applyEffectivePrice(product)
+	}
+
+	product, err := s.repo.FindByPLU(reading.PLU)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyEffectivePrice(product); err != nil {
+		return nil, err
+	}
+
+	// Price-embedded labels carry no weight, so derive it from the product price. That only makes
+	// sense for goods sold by weight or measure, a piece count would round to whole items.
+	if reading.ValueType == models.ScaleValuePrice {
+		if product.MeasureType == models.MeasureCount {
+			return nil, fmt.Errorf("%w: price-embedded scale labels are only valid for products sold by weight or measure", ErrInvalidBarcode)
+		}
+		if product.Price > 0 {
+			reading.Quantity = utils.RoundTo(reading.Price/product.Price, product.QuantityPrecision)
+		}
+	}
+
+	return &models.ScanResult{Product: product, Scale: reading}, nil
+}
+
+// ReceiveStock converts the received quantity and cost to the base unit before updating stock
+func (s *ProductService) ReceiveStock(productID uint, request models.ReceiveStockRequest) (*models.Product, error) {
+	product, err := s.repo.FindByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	factor, _, ok := product.UnitConversion(request.Unit)
+	if !ok {
+		return nil, ErrUnknownUnit
+	}
+
+	baseCost := math.Round(request.UnitCost/factor*100) / 100
+
+	if !product.ValidQuantity(request.Quantity * factor) {
+		return nil, ErrInvalidQuantity
+	}
+
+	quantity := utils.RoundTo(request.Quantity*factor, models.MaxQuantityPrecision)
+
+	return s.repo.ReceiveStock(productID, quantity, baseCost)
+}
+
+func (s *ProductService) GetUnits(productID uint, page models.Page) ([]models.ProductUnit, models.PageInfo, error) {
+	if _, err := s.repo.FindByID(productID); err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	return s.repo.GetUnits(productID, page)
+}
+
+func (s *ProductService) CreateUnit(productID uint, request models.ProductUnitRequest) (*models.ProductUnit, error) {
+	product, err := s.repo.FindByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, exists := product.UnitConversion(request.Name); exists {
+		return nil, ErrDuplicateUnit
+	}
+
+	unit := models.ProductUnit{
+		ProductID:        productID,
+		Name:             request.Name,
+		ConversionFactor: request.ConversionFactor,
+		Price:            request.Price,
+	}
+
+	if err := s.repo.SaveUnit(&unit); err != nil {
+		return nil, err
+	}
+
+	return &unit, nil
+}
+
+func (s *ProductService) UpdateUnit(productID, unitID uint, request models.ProductUnitRequest) (*models.ProductUnit, error) {
+	product, err := s.repo.FindByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	unit, err := s.repo.FindUnit(productID, unitID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Name != unit.Name {
+		if _, _, exists := product.UnitConversion(request.Name); exists {
+			return nil, ErrDuplicateUnit
+		}
+	}
+
+	unit.Name = request.Name
+	unit.ConversionFactor = request.ConversionFactor
+	unit.Price = request.Price
+
+	if err := s.repo.SaveUnit(unit); err != nil {
+		return nil, err
+	}
+
+	return unit, nil
 }
 
-func (s *ProductService) GetAll(name string) ([]models.Product, error) {
-	return s.repo.GetAll(name)
+func (s *ProductService) DeleteUnit(productID, unitID uint) error {
+	unit, err := s.repo.FindUnit(productID, unitID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteUnit(unit)
 }
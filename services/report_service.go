@@ -0,0 +1,456 @@
+package services
+
+import (
+	"Kasir-API/config"
+	"Kasir-API/models"
+	"Kasir-API/repositories"
+	"Kasir-API/utils"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// MaxSeriesBuckets caps a time series so an hourly query over years can't blow up the response
+const MaxSeriesBuckets = 2000
+
+type ReportService struct {
+	repo *repositories.ReportRepository
+}
+
+func NewReportService(repo *repositories.ReportRepository) *ReportService {
+	return &ReportService{repo: repo}
+}
+
+// TimeSeries returns sales in [start, end) bucketed by interval in the store timezone.
+// Buckets without sales are included with zero totals.
+func (s *ReportService) TimeSeries(interval string, start, end time.Time) (*models.SalesTimeSeries, error) {
+	location := config.StoreLocation()
+	start, end = start.In(location), end.In(location)
+
+	first, ok := bucketStart(start, interval)
+	if !ok {
+		return nil, ErrInvalidInterval
+	}
+
+	var starts []time.Time
+	for bucket := first; bucket.Before(end); bucket = nextBucket(bucket, interval) {
+		if len(starts) == MaxSeriesBuckets {
+			return nil, ErrRangeTooLarge
+		}
+		starts = append(starts, bucket)
+	}
+
+	rows, err := s.repo.SalesByBucket(interval, location.String(), start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// The database returns wall-clock times, match them on their local representation
+	totals := make(map[string]repositories.BucketTotals, len(rows))
+	for _, row := range rows {
+		totals[row.Start.Format("2006-01-02T15")] = row
+	}
+
+	series := &models.SalesTimeSeries{
+		Interval: interval,
+		Timezone: location.String(),
+		Start:    start,
+		End:      end,
+		Buckets:  make([]models.SalesBucket, len(starts)),
+	}
+	for i, bucket := range starts {
+		row := totals[bucket.Format("2006-01-02T15")]
+		series.Buckets[i] = models.SalesBucket{
+			Start:        bucket,
+			Revenue:      row.Revenue,
+			Transactions: row.Transactions,
+			ItemsSold:    row.ItemsSold,
+		}
+	}
+
+	return series, nil
+}
+
+// Heatmap returns sales in [start, end) as an hour-of-day by day-of-week matrix in the store timezone
+func (s *ReportService) Heatmap(start, end time.Time) (*models.SalesHeatmap, error) {
+	location := config.StoreLocation()
+
+	rows, err := s.repo.SalesByWeekdayHour(location.String(), start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := &models.SalesHeatmap{
+		Timezone: location.String(),
+		Start:    start.In(location),
+		End:      end.In(location),
+		Days:     make([]models.HeatmapDay, 7),
+	}
+	for i := range heatmap.Days {
+		heatmap.Days[i].Weekday = i + 1
+		heatmap.Days[i].Name = time.Weekday((i + 1) % 7).String()
+	}
+
+	for _, row := range rows {
+		if row.Weekday < 1 || row.Weekday > 7 || row.Hour < 0 || row.Hour > 23 {
+			continue
+		}
+		heatmap.Days[row.Weekday-1].Hours[row.Hour] = models.HeatmapCell{
+			Revenue:      row.Revenue,
+			Transactions: row.Transactions,
+		}
+	}
+
+	return heatmap, nil
+}
+
+// bucketStart truncates t to the start of its bucket, keeping t's location
+func bucketStart(t time.Time, interval string) (time.Time, bool) {
+	year, month, day := t.Date()
+	switch interval {
+	case models.IntervalHour:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, t.Location()), true
+	case models.IntervalDay:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location()), true
+	case models.IntervalWeek:
+		offset := (int(t.Weekday()) + 6) % 7 // Days since Monday
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location()), true
+	case models.IntervalMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location()), true
+	}
+	return time.Time{}, false
+}
+
+func nextBucket(t time.Time, interval string) time.Time {
+	switch interval {
+	case models.IntervalHour:
+		return t.Add(time.Hour)
+	case models.IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case models.IntervalMonth:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// XReport returns the register totals since the last Z-report without closing the day
+func (s *ReportService) XReport() (*models.XReport, error) {
+	now := time.Now()
+
+	var start time.Time
+	last, err := s.repo.LastZReport()
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		start = last.PeriodEnd
+	}
+
+	totals, err := s.repo.RegisterTotals(start, now)
+	if err != nil {
+		return nil, err
+	}
+	applyTax(&totals)
+
+	return &models.XReport{
+		Type:           models.RegisterReportX,
+		GeneratedAt:    now,
+		RegisterTotals: totals,
+	}, nil
+}
+
+// CloseDay stores a Z-report for all sales since the previous one
+func (s *ReportService) CloseDay() (*models.ZReport, error) {
+	now := time.Now()
+
+	return s.repo.CloseDay(now, func(report *models.ZReport) {
+		applyTax(&report.RegisterTotals)
+		report.BusinessDate = now.In(config.StoreLocation()).Format(utils.DateLayout)
+	})
+}
+
+func (s *ReportService) GetZReports(page models.Page) ([]models.ZReport, models.PageInfo, error) {
+	return s.repo.GetZReports(page)
+}
+
+func (s *ReportService) GetZReport(id uint) (*models.ZReport, error) {
+	return s.repo.FindZReport(id)
+}
+
+// applyTax derives net sales and the tax contained in them from TAX_RATE
+func applyTax(totals *models.RegisterTotals) {
+	totals.NetSales = totals.GrossSales - totals.Discounts - totals.Refunds
+	totals.TaxRate = viper.GetFloat64("TAX_RATE")
+	if totals.TaxRate > 0 {
+		totals.Tax = int(math.Round(float64(totals.NetSales) * totals.TaxRate / (100 + totals.TaxRate)))
+	}
+}
+
+// InventoryValuation values the stock on hand at cost and retail, now or as of a past moment
+func (s *ReportService) InventoryValuation(asOf *time.Time) (*models.InventoryValuation, error) {
+	now := time.Now()
+	if asOf != nil && !asOf.Before(now) {
+		asOf = nil
+	}
+
+	lines, err := s.repo.InventoryAt(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	valuation := &models.InventoryValuation{
+		AsOf:          now,
+		Reconstructed: asOf != nil,
+		Products:      make([]models.InventoryValuationLine, 0, len(lines)),
+	}
+	if asOf != nil {
+		valuation.AsOf = *asOf
+	}
+
+	byCategory := make(map[uint]*models.CategoryValuation)
+	for _, line := range lines {
+		if line.Quantity == 0 {
+			continue
+		}
+		line.CostValue = roundMoney(line.Quantity * line.UnitCost)
+		line.RetailValue = roundMoney(line.Quantity * line.UnitPrice)
+		valuation.Products = append(valuation.Products, line)
+
+		subtotal, ok := byCategory[line.CategoryID]
+		if !ok {
+			subtotal = &models.CategoryValuation{CategoryID: line.CategoryID, Path: line.Category}
+			byCategory[line.CategoryID] = subtotal
+		}
+		subtotal.Products++
+		subtotal.CostValue += line.CostValue
+		subtotal.RetailValue += line.RetailValue
+
+		valuation.TotalCostValue += line.CostValue
+		valuation.TotalRetailValue += line.RetailValue
+	}
+
+	valuation.Categories = make([]models.CategoryValuation, 0, len(byCategory))
+	for _, subtotal := range byCategory {
+		subtotal.CostValue = roundMoney(subtotal.CostValue)
+		subtotal.RetailValue = roundMoney(subtotal.RetailValue)
+		valuation.Categories = append(valuation.Categories, *subtotal)
+	}
+	sort.Slice(valuation.Categories, func(i, j int) bool {
+		return valuation.Categories[i].Path < valuation.Categories[j].Path
+	})
+	sort.SliceStable(valuation.Products, func(i, j int) bool {
+		return valuation.Products[i].Category < valuation.Products[j].Category
+	})
+
+	valuation.TotalCostValue = roundMoney(valuation.TotalCostValue)
+	valuation.TotalRetailValue = roundMoney(valuation.TotalRetailValue)
+
+	return valuation, nil
+}
+
+// roundMoney rounds an amount to 2 decimals
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// DeadStock lists products that did not sell in the last days days or sold below maxSellThrough percent
+func (s *ReportService) DeadStock(days int, maxSellThrough float64) (*models.DeadStockReport, error) {
+	now := time.Now()
+	since := now.AddDate(0, 0, -days)
+
+	products, err := s.repo.SlowMovers(since, maxSellThrough)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DeadStockReport{
+		Days:           days,
+		Since:          since,
+		MaxSellThrough: maxSellThrough,
+		Products:       products,
+	}
+
+	for i := range report.Products {
+		product := &report.Products[i]
+		product.StockValue = roundMoney(product.Stock * product.CostPrice)
+		report.TotalStockValue += product.StockValue
+
+		if product.QtySold == 0 {
+			product.Status = models.StockStatusDead
+			continue
+		}
+
+		product.Status = models.StockStatusSlow
+		product.SellThrough = roundMoney(product.QtySold / (product.QtySold + product.Stock) * 100)
+		cover := roundMoney(product.Stock / (product.QtySold / float64(days)))
+		product.DaysOfCover = &cover
+	}
+	report.TotalStockValue = roundMoney(report.TotalStockValue)
+
+	return report, nil
+}
+
+// BasketPairs mines the transactions in [start, end) for products bought together
+func (s *ReportService) BasketPairs(start, end time.Time, minTogether, limit int) (*models.BasketAnalysis, error) {
+	baskets, err := s.repo.CountBaskets(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs, err := s.repo.ProductPairs(start, end, minTogether, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pairs {
+		pair := &pairs[i]
+		pair.Support = roundRatio(float64(pair.Together) / float64(baskets))
+		pair.ConfidenceA = roundRatio(float64(pair.Together) / float64(pair.CountA))
+		pair.ConfidenceB = roundRatio(float64(pair.Together) / float64(pair.CountB))
+		pair.Lift = roundRatio(float64(pair.Together) * float64(baskets) / (float64(pair.CountA) * float64(pair.CountB)))
+	}
+
+	return &models.BasketAnalysis{
+		Start:       start,
+		End:         end,
+		Baskets:     baskets,
+		MinTogether: minTogether,
+		Pairs:       pairs,
+	}, nil
+}
+
+// AlsoBought suggests products for productID, the ones most likely to end up in the same basket first
+func (s *ReportService) AlsoBought(productID uint, start, end time.Time, minTogether, limit int) ([]models.AlsoBoughtProduct, error) {
+	baskets, err := s.repo.CountBaskets(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	productCount, products, err := s.repo.AlsoBought(productID, start, end, minTogether)
+	if err != nil {
+		return nil, err
+	}
+	if productCount == 0 {
+		return []models.AlsoBoughtProduct{}, nil
+	}
+
+	for i := range products {
+		product := &products[i]
+		product.Confidence = roundRatio(float64(product.Together) / float64(productCount))
+		product.Lift = roundRatio(float64(product.Together) * float64(baskets) / (float64(productCount) * float64(product.Count)))
+	}
+
+	sort.SliceStable(products, func(i, j int) bool {
+		if products[i].Confidence != products[j].Confidence {
+			return products[i].Confidence > products[j].Confidence
+		}
+		if products[i].Lift != products[j].Lift {
+			return products[i].Lift > products[j].Lift
+		}
+		return products[i].ProductID < products[j].ProductID
+	})
+	if len(products) > limit {
+		products = products[:limit]
+	}
+
+	return products, nil
+}
+
+// roundRatio rounds a ratio to 4 decimals
+func roundRatio(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// ABCAnalysis classifies the products by their share of the revenue in [start, end). A product is
+// class A while the share of the products ranked above it is below thresholdA percent, B while it
+// is below thresholdB and C otherwise. Products without revenue are always C. Nil thresholds use
+// ABC_THRESHOLD_A and ABC_THRESHOLD_B.
+func (s *ReportService) ABCAnalysis(start, end time.Time, categoryID *uint, a, b *float64) (*models.ABCAnalysis, error) {
+	thresholdA, thresholdB := viper.GetFloat64("ABC_THRESHOLD_A"), viper.GetFloat64("ABC_THRESHOLD_B")
+	if a != nil {
+		thresholdA = *a
+	}
+	if b != nil {
+		thresholdB = *b
+	}
+	if thresholdA <= 0 || thresholdA >= thresholdB || thresholdB > 100 {
+		return nil, ErrInvalidABCThresholds
+	}
+
+	products, err := s.repo.ProductRevenue(start, end, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &models.ABCAnalysis{
+		Start:      start,
+		End:        end,
+		CategoryID: categoryID,
+		ThresholdA: thresholdA,
+		ThresholdB: thresholdB,
+		Products:   products,
+	}
+	classifyABC(analysis)
+
+	return analysis, nil
+}
+
+// classifyABC ranks the analysis products, already sorted by revenue, and fills in their shares,
+// classes and the per-class summaries
+func classifyABC(analysis *models.ABCAnalysis) {
+	for _, product := range analysis.Products {
+		analysis.TotalRevenue += product.Revenue
+	}
+
+	classes := map[string]*models.ABCClassSummary{
+		models.ABCClassA: {Class: models.ABCClassA},
+		models.ABCClassB: {Class: models.ABCClassB},
+		models.ABCClassC: {Class: models.ABCClassC},
+	}
+
+	cumulative := 0
+	for i := range analysis.Products {
+		product := &analysis.Products[i]
+		product.Rank = i + 1
+
+		// The share ranked above this product, scaled by the total instead of divided by it so a
+		// share exactly at a threshold is not pushed below it by float rounding
+		before := float64(cumulative) * 100
+		total := float64(analysis.TotalRevenue)
+		if analysis.TotalRevenue > 0 {
+			product.RevenuePct = roundMoney(float64(product.Revenue) / total * 100)
+		}
+		cumulative += product.Revenue
+		if analysis.TotalRevenue > 0 {
+			product.CumulativePct = roundMoney(float64(cumulative) / total * 100)
+		}
+
+		switch {
+		case product.Revenue <= 0:
+			product.Class = models.ABCClassC
+		case before < analysis.ThresholdA*total:
+			product.Class = models.ABCClassA
+		case before < analysis.ThresholdB*total:
+			product.Class = models.ABCClassB
+		default:
+			product.Class = models.ABCClassC
+		}
+
+		summary := classes[product.Class]
+		summary.Products++
+		summary.Revenue += product.Revenue
+	}
+
+	for _, class := range []string{models.ABCClassA, models.ABCClassB, models.ABCClassC} {
+		summary := classes[class]
+		if len(analysis.Products) > 0 {
+			summary.ProductsPct = roundMoney(float64(summary.Products) / float64(len(analysis.Products)) * 100)
+		}
+		if analysis.TotalRevenue > 0 {
+			summary.RevenuePct = roundMoney(float64(summary.Revenue) / float64(analysis.TotalRevenue) * 100)
+		}
+		analysis.Classes = append(analysis.Classes, *summary)
+	}
+}
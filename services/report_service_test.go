@@ -0,0 +1,130 @@
+package services
+
+import (
+	"Kasir-API/models"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClassifyABC(t *testing.T) {
+	tests := []struct {
+		name        string
+		revenues    []int
+		thresholdA  float64
+		thresholdB  float64
+		wantClasses []string
+		wantSummary [3]int // Products in A, B and C
+	}{
+		{
+			// The second product starts exactly at 50%, the third exactly at 80%
+			name:        "shares exactly at the thresholds",
+			revenues:    []int{50, 30, 20},
+			thresholdA:  50,
+			thresholdB:  80,
+			wantClasses: []string{"A", "B", "C"},
+			wantSummary: [3]int{1, 1, 1},
+		},
+		{
+			// 29 / 100 * 100 is 28.999999999999996 in floating point
+			name:        "threshold not representable as a share",
+			revenues:    []int{29, 51, 20},
+			thresholdA:  29,
+			thresholdB:  80,
+			wantClasses: []string{"A", "B", "C"},
+			wantSummary: [3]int{1, 1, 1},
+		},
+		{
+			// The third product starts at 79%, the fourth at 94%
+			name:        "shares just below the thresholds",
+			revenues:    []int{40, 39, 15, 6},
+			thresholdA:  80,
+			thresholdB:  95,
+			wantClasses: []string{"A", "A", "A", "B"},
+			wantSummary: [3]int{3, 1, 0},
+		},
+		{
+			name:        "products without revenue are C",
+			revenues:    []int{100, 0, 0},
+			thresholdA:  80,
+			thresholdB:  95,
+			wantClasses: []string{"A", "C", "C"},
+			wantSummary: [3]int{1, 0, 2},
+		},
+		{
+			name:        "no revenue at all",
+			revenues:    []int{0, 0},
+			thresholdA:  80,
+			thresholdB:  95,
+			wantClasses: []string{"C", "C"},
+			wantSummary: [3]int{0, 0, 2},
+		},
+		{
+			name:        "single product",
+			revenues:    []int{1000},
+			thresholdA:  80,
+			thresholdB:  95,
+			wantClasses: []string{"A"},
+			wantSummary: [3]int{1, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := &models.ABCAnalysis{ThresholdA: tt.thresholdA, ThresholdB: tt.thresholdB}
+			for i, revenue := range tt.revenues {
+				analysis.Products = append(analysis.Products, models.ABCProduct{ProductID: uint(i + 1), Revenue: revenue})
+			}
+
+			classifyABC(analysis)
+
+			var classes []string
+			for i, product := range analysis.Products {
+				classes = append(classes, product.Class)
+				if product.Rank != i+1 {
+					t.Errorf("product %d rank = %d, want %d", product.ProductID, product.Rank, i+1)
+				}
+			}
+			if !reflect.DeepEqual(classes, tt.wantClasses) {
+				t.Errorf("classes = %v, want %v", classes, tt.wantClasses)
+			}
+
+			var summary [3]int
+			for i, class := range analysis.Classes {
+				summary[i] = class.Products
+			}
+			if summary != tt.wantSummary {
+				t.Errorf("products per class = %v, want %v", summary, tt.wantSummary)
+			}
+
+			if last := analysis.Products[len(analysis.Products)-1]; analysis.TotalRevenue > 0 && last.CumulativePct != 100 {
+				t.Errorf("last cumulative_pct = %v, want 100", last.CumulativePct)
+			}
+		})
+	}
+}
+
+func TestABCAnalysisThresholds(t *testing.T) {
+	pct := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name string
+		a, b *float64
+	}{
+		{name: "zero A", a: pct(0), b: pct(95)},
+		{name: "A equal to B", a: pct(80), b: pct(80)},
+		{name: "A above B", a: pct(90), b: pct(80)},
+		{name: "B above 100", a: pct(80), b: pct(101)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Invalid thresholds are refused before the revenue is queried
+			_, err := (&ReportService{}).ABCAnalysis(time.Time{}, time.Time{}, nil, tt.a, tt.b)
+			if !errors.Is(err, ErrInvalidABCThresholds) {
+				t.Errorf("ABCAnalysis(%v, %v) error = %v, want ErrInvalidABCThresholds", *tt.a, *tt.b, err)
+			}
+		})
+	}
+}
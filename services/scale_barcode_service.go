@@ -0,0 +1,125 @@
+package services
+
+import (
+	"Kasir-API/models"
+	"Kasir-API/repositories"
+	"Kasir-API/utils"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type ScaleBarcodeService struct {
+	repo *repositories.ScaleBarcodeRepository
+}
+
+func NewScaleBarcodeService(repo *repositories.ScaleBarcodeRepository) *ScaleBarcodeService {
+	return &ScaleBarcodeService{repo: repo}
+}
+
+func (s *ScaleBarcodeService) GetAll(page models.Page) ([]models.ScaleBarcodeRule, models.PageInfo, error) {
+	return s.repo.GetAll(page)
+}
+
+func (s *ScaleBarcodeService) Create(request models.ScaleBarcodeRuleRequest) (*models.ScaleBarcodeRule, error) {
+	if err := validateScaleRule(request); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.FindByPrefix(request.Prefix); err == nil {
+		return nil, ErrDuplicatePrefix
+	}
+
+	rule := models.ScaleBarcodeRule{}
+	applyScaleRule(&rule, request)
+
+	if err := s.repo.Save(&rule); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (s *ScaleBarcodeService) Update(id uint, request models.ScaleBarcodeRuleRequest) (*models.ScaleBarcodeRule, error) {
+	rule, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateScaleRule(request); err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.repo.FindByPrefix(request.Prefix); err == nil && existing.ID != rule.ID {
+		return nil, ErrDuplicatePrefix
+	}
+
+	applyScaleRule(rule, request)
+
+	if err := s.repo.Save(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *ScaleBarcodeService) Delete(id uint) error {
+	rule, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(rule)
+}
+
+// Decode parses a scale-printed barcode. It returns nil without error when no rule matches the prefix.
+func (s *ScaleBarcodeService) Decode(code string) (*models.ScaleReading, error) {
+	if !utils.IsScaleBarcode(code) {
+		return nil, nil
+	}
+
+	rule, err := s.repo.FindByPrefix(code[:2])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	plu, value, err := utils.DecodeScaleBarcode(code, rule.PLULength, rule.ValueLength, rule.ValueDecimals)
+	if err != nil {
+		return nil, err
+	}
+
+	reading := models.ScaleReading{
+		Prefix:    rule.Prefix,
+		PLU:       plu,
+		ValueType: rule.ValueType,
+	}
+	if rule.ValueType == models.ScaleValuePrice {
+		reading.Price = value
+	} else {
+		reading.Quantity = value
+	}
+
+	return &reading, nil
+}
+
+func validateScaleRule(request models.ScaleBarcodeRuleRequest) error {
+	if request.Prefix < "20" || request.Prefix > "29" {
+		return fmt.Errorf("%w: prefix must be between 20 and 29", ErrInvalidScaleRule)
+	}
+	if 2+request.PLULength+request.ValueLength > 12 {
+		return fmt.Errorf("%w: plu_length + value_length cannot exceed 10 digits", ErrInvalidScaleRule)
+	}
+	return nil
+}
+
+func applyScaleRule(rule *models.ScaleBarcodeRule, request models.ScaleBarcodeRuleRequest) {
+	rule.Prefix = request.Prefix
+	rule.PLULength = request.PLULength
+	rule.ValueType = request.ValueType
+	rule.ValueLength = request.ValueLength
+	rule.ValueDecimals = request.ValueDecimals
+	rule.Description = request.Description
+}
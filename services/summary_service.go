@@ -0,0 +1,68 @@
+package services
+
+import (
+	"Kasir-API/config"
+	"Kasir-API/repositories"
+	"log"
+	"time"
+)
+
+type SummaryService struct {
+	repo *repositories.SummaryRepository
+}
+
+func NewSummaryService(repo *repositories.SummaryRepository) *SummaryService {
+	return &SummaryService{repo: repo}
+}
+
+// Rebuild recomputes the daily summaries for the store-local days from..to (inclusive).
+// A nil from starts at the first sale, a nil to ends today.
+func (s *SummaryService) Rebuild(from, to *time.Time) (int, error) {
+	location := config.StoreLocation()
+	now := time.Now().In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	end := today.AddDate(0, 0, 1)
+	if to != nil {
+		end = to.AddDate(0, 0, 1)
+	}
+
+	start := today
+	if from != nil {
+		start = *from
+	} else {
+		first, err := s.repo.FirstSaleAt()
+		if err != nil {
+			return 0, err
+		}
+		if first == nil {
+			return 0, nil
+		}
+		local := first.In(location)
+		start = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	}
+
+	return s.repo.Rebuild(start, end)
+}
+
+// BackfillIfEmpty fills the summaries from the full history the first time the server runs with
+// them, so existing installations don't need to run the rebuild command by hand. It must finish
+// before checkouts are accepted, a sale saved first would leave the summaries non-empty and the
+// history before it would never be filled.
+func (s *SummaryService) BackfillIfEmpty() error {
+	needed, err := s.repo.NeedsBackfill()
+	if err != nil {
+		return err
+	}
+	if !needed {
+		return nil
+	}
+
+	log.Println("📊 Backfilling daily sales summaries...")
+	days, err := s.Rebuild(nil, nil)
+	if err != nil {
+		return err
+	}
+	log.Printf("✅ Daily sales summaries backfilled for %d days", days)
+	return nil
+}
@@ -1,18 +1,26 @@
 package services
 
 import (
-	"Kasir-API/database"
+	"Kasir-API/config"
 	"Kasir-API/models"
 	"Kasir-API/repositories"
+	"Kasir-API/utils"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/spf13/viper"
 )
 
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo     *repositories.TransactionRepository
+	products *ProductService
 }
 
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+func NewTransactionService(repo *repositories.TransactionRepository, products *ProductService) *TransactionService {
+	return &TransactionService{repo: repo, products: products}
 }
 
 func (s *TransactionService) Checkout(request models.CheckoutRequest) (*models.Transaction, error) {
@@ -24,26 +32,55 @@ func (s *TransactionService) Checkout(request models.CheckoutRequest) (*models.T
 	var totalAmount int
 	var details []models.TransactionDetail
 
-	db := database.GetDB()
-
 	for _, item := range request.Items {
-		var product models.Product
-		if err := db.First(&product, item.ProductID).Error; err != nil {
-			return nil, fmt.Errorf("product ID %d not found", item.ProductID)
+		product, reading, err := s.resolveProduct(item)
+		if err != nil {
+			return nil, err
 		}
 
-		if product.Stock < item.Quantity {
+		// Scale labels carry their own weight (and price), so they become a line as printed
+		if reading != nil {
+			detail, err := scaleLine(product, reading)
+			if err != nil {
+				return nil, err
+			}
+			totalAmount += detail.Subtotal
+			details = append(details, detail)
+			continue
+		}
+
+		factor, unitPrice, ok := product.UnitConversion(item.Unit)
+		if !ok {
+			return nil, fmt.Errorf("unit %s is not defined for product: %s", item.Unit, product.Name)
+		}
+
+		// Precision applies to the base unit the stock is kept in, like when receiving stock
+		if !product.ValidQuantity(item.Quantity * factor) {
+			return nil, fmt.Errorf("invalid quantity %v for product: %s (max %d decimals in %s)", item.Quantity, product.Name, product.QuantityPrecision, product.BaseUnit)
+		}
+
+		// Stock is kept in base units
+		baseQuantity := utils.RoundTo(item.Quantity*factor, models.MaxQuantityPrecision)
+		if product.Stock < baseQuantity {
 			return nil, fmt.Errorf("insufficient stock for product: %s", product.Name)
 		}
 
-		subtotal := int(product.Price) * item.Quantity
+		// Round each line to whole rupiah
+		subtotal := int(math.Round(unitPrice * item.Quantity))
 		totalAmount += subtotal
 
+		unit := item.Unit
+		if unit == "" {
+			unit = product.BaseUnit
+		}
+
 		details = append(details, models.TransactionDetail{
-			ProductID:   item.ProductID,
-			ProductName: product.Name,
-			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
+			ProductID:    product.ID,
+			ProductName:  product.Name,
+			Quantity:     baseQuantity,
+			Unit:         unit,
+			UnitQuantity: item.Quantity,
+			Subtotal:     subtotal,
 		})
 	}
 
@@ -57,10 +94,211 @@ func (s *TransactionService) Checkout(request models.CheckoutRequest) (*models.T
 	return &transaction, nil
 }
 
-func (s *TransactionService) GetAll() ([]models.Transaction, error) {
-	return s.repo.GetAll()
+// resolveProduct finds the product for a checkout item by product_id or scanned barcode
+func (s *TransactionService) resolveProduct(item models.CheckoutItem) (*models.Product, *models.ScaleReading, error) {
+	if item.ProductID != 0 {
+		product, err := s.products.GetByID(item.ProductID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("product ID %d not found", item.ProductID)
+		}
+		return product, nil, nil
+	}
+
+	if item.Barcode != "" {
+		result, err := s.products.Scan(item.Barcode)
+		if err != nil {
+			if errors.Is(err, ErrInvalidBarcode) {
+				return nil, nil, err
+			}
+			return nil, nil, fmt.Errorf("product with barcode %s not found", item.Barcode)
+		}
+		if result.Scale != nil && item.Unit != "" {
+			return nil, nil, fmt.Errorf("unit cannot be set for scale barcode %s", item.Barcode)
+		}
+		return result.Product, result.Scale, nil
+	}
+
+	return nil, nil, fmt.Errorf("each item requires a product_id or barcode")
+}
+
+// scaleLine builds a transaction detail from a scale reading in the product's base unit
+func scaleLine(product *models.Product, reading *models.ScaleReading) (models.TransactionDetail, error) {
+	quantity := utils.RoundTo(reading.Quantity, models.MaxQuantityPrecision)
+	if quantity <= 0 {
+		return models.TransactionDetail{}, fmt.Errorf("scale barcode for product %s has no quantity", product.Name)
+	}
+	if !product.ValidQuantity(quantity) {
+		return models.TransactionDetail{}, fmt.Errorf("invalid quantity %v for product: %s (max %d decimals)", quantity, product.Name, product.QuantityPrecision)
+	}
+
+	if product.Stock < quantity {
+		return models.TransactionDetail{}, fmt.Errorf("insufficient stock for product: %s", product.Name)
+	}
+
+	subtotal := int(math.Round(product.Price * quantity))
+	if reading.ValueType == models.ScaleValuePrice {
+		subtotal = int(math.Round(reading.Price))
+	}
+
+	return models.TransactionDetail{
+		ProductID:    product.ID,
+		ProductName:  product.Name,
+		Quantity:     quantity,
+		Unit:         product.BaseUnit,
+		UnitQuantity: quantity,
+		Subtotal:     subtotal,
+	}, nil
+}
+
+func (s *TransactionService) GetAll(page models.Page) ([]models.Transaction, models.PageInfo, error) {
+	return s.repo.GetAll(page)
+}
+
+// isStoreMidnight reports whether t is the start of a day in the store timezone
+func isStoreMidnight(t time.Time) bool {
+	local := t.In(config.StoreLocation())
+	return local.Hour() == 0 && local.Minute() == 0 && local.Second() == 0 && local.Nanosecond() == 0
+}
+
+func (s *TransactionService) GetReport(query models.ReportQuery) (models.ReportResponse, error) {
+	if query.TopN <= 0 {
+		query.TopN = models.DefaultReportTopN
+	}
+	if query.TopN > models.MaxReportTopN {
+		query.TopN = models.MaxReportTopN
+	}
+
+	report, err := s.report(query)
+	if err != nil || query.Compare == models.CompareNone {
+		return report, err
+	}
+
+	start, end, err := comparisonRange(query)
+	if err != nil {
+		return report, err
+	}
+
+	compareQuery := query
+	compareQuery.Start, compareQuery.End = start, end
+	previous, err := s.report(compareQuery)
+	if err != nil {
+		return report, err
+	}
+
+	report.Comparison = compareReports(query.Compare, report, previous)
+	return report, nil
+}
+
+func (s *TransactionService) report(query models.ReportQuery) (models.ReportResponse, error) {
+	// Long ranges of whole days are served from the daily summaries
+	days := query.End.Sub(query.Start).Hours() / 24
+	query.FromSummaries = days >= viper.GetFloat64("SUMMARY_MIN_DAYS") && isStoreMidnight(query.Start) && isStoreMidnight(query.End)
+	return s.repo.GetReport(query)
+}
+
+// comparisonRange returns the period a report is compared with
+func comparisonRange(query models.ReportQuery) (time.Time, time.Time, error) {
+	location := config.StoreLocation()
+	start, end := query.Start.In(location), query.End.In(location)
+
+	switch query.Compare {
+	case models.ComparePrevious:
+		// Whole days move by calendar days so a daylight saving change cannot shift the boundaries
+		if isStoreMidnight(start) && isStoreMidnight(end) {
+			days := int(math.Round(end.Sub(start).Hours() / 24))
+			return start.AddDate(0, 0, -days), start, nil
+		}
+		return start.Add(-end.Sub(start)), start, nil
+	case models.CompareLastYear:
+		// Feb 29 has no counterpart a year earlier and AddDate would move it to Mar 1, which turns a
+		// leap day report into an empty range. The start falls back to Feb 28, so does an end during
+		// Feb 29. An end at midnight is exclusive and Mar 1 is already right for it.
+		previousStart := yearEarlier(start)
+		previousEnd := end.AddDate(-1, 0, 0)
+		if !isStoreMidnight(end) {
+			previousEnd = yearEarlier(end)
+		}
+		if !previousEnd.After(previousStart) {
+			// Only a range of hours across the end of Feb 28 folds onto itself, keep its length
+			previousEnd = previousStart.Add(end.Sub(start))
+		}
+		return previousStart, previousEnd, nil
+	case models.CompareCustom:
+		if query.CompareStart.IsZero() || !query.CompareEnd.After(query.CompareStart) {
+			return time.Time{}, time.Time{}, ErrInvalidComparison
+		}
+		return query.CompareStart, query.CompareEnd, nil
+	}
+
+	return time.Time{}, time.Time{}, ErrInvalidComparison
+}
+
+// yearEarlier returns the same wall clock time a year earlier, Feb 29 becomes Feb 28 instead of Mar 1
+func yearEarlier(t time.Time) time.Time {
+	if t.Month() == time.February && t.Day() == 29 {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t.AddDate(-1, 0, 0)
 }
 
-func (s *TransactionService) GetReport(startDate, endDate string) (models.ReportResponse, error) {
-	return s.repo.GetReport(startDate, endDate)
+// compareReports puts the headline figures and the best sellers by revenue next to the comparison period
+func compareReports(mode string, current, previous models.ReportResponse) *models.ReportComparison {
+	comparison := &models.ReportComparison{
+		Mode:           mode,
+		Start:          previous.Start,
+		End:            previous.End,
+		Source:         previous.Source,
+		TotalRevenue:   metricDelta(float64(current.TotalRevenue), float64(previous.TotalRevenue)),
+		TotalTransaksi: metricDelta(float64(current.TotalTransaksi), float64(previous.TotalTransaksi)),
+		AverageBasket:  metricDelta(current.AverageBasket, previous.AverageBasket),
+		GrossProfit:    metricDelta(float64(current.GrossProfit), float64(previous.GrossProfit)),
+		TopProducts:    []models.TopSellerComparison{},
+	}
+
+	// Rank every product of the comparison period by revenue, ties to the lower product ID like the top lists
+	ranked := make([]models.ProductProfit, len(previous.PerProduct))
+	copy(ranked, previous.PerProduct)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Revenue != ranked[j].Revenue {
+			return ranked[i].Revenue > ranked[j].Revenue
+		}
+		return ranked[i].ProductID < ranked[j].ProductID
+	})
+	previousRank := make(map[uint]int, len(ranked))
+	previousSales := make(map[uint]models.ProductProfit, len(ranked))
+	for i, row := range ranked {
+		previousRank[row.ProductID] = i + 1
+		previousSales[row.ProductID] = row
+	}
+
+	for _, seller := range current.TopByRevenue {
+		entry := models.TopSellerComparison{
+			Rank:      seller.Rank,
+			ProductID: seller.ProductID,
+			Name:      seller.Name,
+		}
+		before := previousSales[seller.ProductID]
+		if rank, ok := previousRank[seller.ProductID]; ok {
+			entry.PreviousRank = &rank
+		}
+		entry.Revenue = metricDelta(float64(seller.Revenue), float64(before.Revenue))
+		entry.QtySold = metricDelta(seller.QtySold, before.QtySold)
+		comparison.TopProducts = append(comparison.TopProducts, entry)
+	}
+
+	return comparison
+}
+
+// metricDelta returns the absolute change and the change as a percentage of the previous value
+func metricDelta(current, previous float64) models.MetricDelta {
+	delta := models.MetricDelta{
+		Current:  current,
+		Previous: previous,
+		Change:   utils.RoundTo(current-previous, models.MaxQuantityPrecision),
+	}
+	if previous != 0 {
+		pct := math.Round((current-previous)/math.Abs(previous)*10000) / 100
+		delta.ChangePct = &pct
+	}
+	return delta
 }
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"Kasir-API/config"
+	"Kasir-API/models"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMetricDelta(t *testing.T) {
+	pct := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name              string
+		current, previous float64
+		want              models.MetricDelta
+	}{
+		{name: "growth", current: 150, previous: 100, want: models.MetricDelta{Current: 150, Previous: 100, Change: 50, ChangePct: pct(50)}},
+		{name: "decline", current: 50, previous: 100, want: models.MetricDelta{Current: 50, Previous: 100, Change: -50, ChangePct: pct(-50)}},
+		{name: "unchanged", current: 100, previous: 100, want: models.MetricDelta{Current: 100, Previous: 100, Change: 0, ChangePct: pct(0)}},
+		{name: "rounded to two decimals", current: 2, previous: 3, want: models.MetricDelta{Current: 2, Previous: 3, Change: -1, ChangePct: pct(-33.33)}},
+		{name: "fractional quantities", current: 1.1, previous: 1, want: models.MetricDelta{Current: 1.1, Previous: 1, Change: 0.1, ChangePct: pct(10)}},
+		{name: "recovery from a loss", current: 50, previous: -50, want: models.MetricDelta{Current: 50, Previous: -50, Change: 100, ChangePct: pct(200)}},
+		{name: "nothing before", current: 100, previous: 0, want: models.MetricDelta{Current: 100, Previous: 0, Change: 100}},
+		{name: "nothing at all", current: 0, previous: 0, want: models.MetricDelta{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metricDelta(tt.current, tt.previous); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("metricDelta(%v, %v) = %s, want %s", tt.current, tt.previous, describeDelta(got), describeDelta(tt.want))
+			}
+		})
+	}
+}
+
+func TestCompareReports(t *testing.T) {
+	rank := func(value int) *int { return &value }
+	pct := func(value float64) *float64 { return &value }
+
+	previousStart := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	previousEnd := previousStart.AddDate(0, 0, 7)
+
+	current := models.ReportResponse{
+		TotalRevenue:   600,
+		TotalTransaksi: 6,
+		AverageBasket:  100,
+		GrossProfit:    150,
+		TopByRevenue: []models.TopSeller{
+			{Rank: 1, ProductID: 1, Name: "Kopi", QtySold: 3, Revenue: 300},
+			{Rank: 2, ProductID: 2, Name: "Teh", QtySold: 2, Revenue: 200},
+			{Rank: 3, ProductID: 3, Name: "Gula", QtySold: 1.5, Revenue: 100},
+		},
+	}
+	previous := models.ReportResponse{
+		Start:          previousStart,
+		End:            previousEnd,
+		Source:         models.ReportSourceSummary,
+		TotalRevenue:   700,
+		TotalTransaksi: 7,
+		AverageBasket:  100,
+		GrossProfit:    0,
+		// Kopi and Susu tie on revenue, the lower product ID ranks first
+		PerProduct: []models.ProductProfit{
+			{ProductID: 4, Name: "Susu", QtySold: 2, Revenue: 100},
+			{ProductID: 1, Name: "Kopi", QtySold: 1, Revenue: 100},
+			{ProductID: 2, Name: "Teh", QtySold: 5, Revenue: 500},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		check func(t *testing.T, got *models.ReportComparison)
+	}{
+		{name: "period", check: func(t *testing.T, got *models.ReportComparison) {
+			if got.Mode != models.ComparePrevious || !got.Start.Equal(previousStart) || !got.End.Equal(previousEnd) || got.Source != models.ReportSourceSummary {
+				t.Errorf("period = %s [%v, %v) %s, want %s [%v, %v) %s", got.Mode, got.Start, got.End, got.Source,
+					models.ComparePrevious, previousStart, previousEnd, models.ReportSourceSummary)
+			}
+		}},
+		{name: "totals", check: func(t *testing.T, got *models.ReportComparison) {
+			want := []models.MetricDelta{
+				{Current: 600, Previous: 700, Change: -100, ChangePct: pct(-14.29)},
+				{Current: 6, Previous: 7, Change: -1, ChangePct: pct(-14.29)},
+				{Current: 100, Previous: 100, Change: 0, ChangePct: pct(0)},
+				{Current: 150, Previous: 0, Change: 150},
+			}
+			for i, delta := range []models.MetricDelta{got.TotalRevenue, got.TotalTransaksi, got.AverageBasket, got.GrossProfit} {
+				if !reflect.DeepEqual(delta, want[i]) {
+					t.Errorf("total %d = %s, want %s", i, describeDelta(delta), describeDelta(want[i]))
+				}
+			}
+		}},
+		{name: "top products", check: func(t *testing.T, got *models.ReportComparison) {
+			want := []models.TopSellerComparison{
+				{Rank: 1, PreviousRank: rank(2), ProductID: 1, Name: "Kopi",
+					Revenue: models.MetricDelta{Current: 300, Previous: 100, Change: 200, ChangePct: pct(200)},
+					QtySold: models.MetricDelta{Current: 3, Previous: 1, Change: 2, ChangePct: pct(200)}},
+				{Rank: 2, PreviousRank: rank(1), ProductID: 2, Name: "Teh",
+					Revenue: models.MetricDelta{Current: 200, Previous: 500, Change: -300, ChangePct: pct(-60)},
+					QtySold: models.MetricDelta{Current: 2, Previous: 5, Change: -3, ChangePct: pct(-60)}},
+				{Rank: 3, ProductID: 3, Name: "Gula",
+					Revenue: models.MetricDelta{Current: 100, Change: 100},
+					QtySold: models.MetricDelta{Current: 1.5, Change: 1.5}},
+			}
+			if !reflect.DeepEqual(got.TopProducts, want) {
+				t.Errorf("top products = %+v, want %+v", got.TopProducts, want)
+			}
+		}},
+	}
+
+	got := compareReports(models.ComparePrevious, current, previous)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, got)
+		})
+	}
+
+	t.Run("no sales in either period", func(t *testing.T) {
+		got := compareReports(models.CompareLastYear, models.ReportResponse{}, models.ReportResponse{})
+		if got.TopProducts == nil || len(got.TopProducts) != 0 {
+			t.Errorf("top products = %#v, want an empty list", got.TopProducts)
+		}
+		if got.TotalRevenue.ChangePct != nil {
+			t.Errorf("total revenue change_pct = %v, want nil", *got.TotalRevenue.ChangePct)
+		}
+	})
+}
+
+func TestComparisonRangeLastYear(t *testing.T) {
+	location := config.StoreLocation()
+	at := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, location)
+	}
+
+	tests := []struct {
+		name               string
+		start, end         time.Time
+		wantStart, wantEnd time.Time
+	}{
+		{name: "regular day", start: at(2024, 3, 5, 0), end: at(2024, 3, 6, 0), wantStart: at(2023, 3, 5, 0), wantEnd: at(2023, 3, 6, 0)},
+		{name: "leap day", start: at(2024, 2, 29, 0), end: at(2024, 3, 1, 0), wantStart: at(2023, 2, 28, 0), wantEnd: at(2023, 3, 1, 0)},
+		{name: "day before the leap day", start: at(2024, 2, 28, 0), end: at(2024, 2, 29, 0), wantStart: at(2023, 2, 28, 0), wantEnd: at(2023, 3, 1, 0)},
+		{name: "february", start: at(2024, 2, 1, 0), end: at(2024, 3, 1, 0), wantStart: at(2023, 2, 1, 0), wantEnd: at(2023, 3, 1, 0)},
+		{name: "hours on the leap day", start: at(2024, 2, 29, 8), end: at(2024, 2, 29, 17), wantStart: at(2023, 2, 28, 8), wantEnd: at(2023, 2, 28, 17)},
+		{name: "hours across the end of Feb 28", start: at(2024, 2, 28, 22), end: at(2024, 2, 29, 2), wantStart: at(2023, 2, 28, 22), wantEnd: at(2023, 3, 1, 2)},
+		{name: "from a leap year", start: at(2025, 2, 1, 0), end: at(2025, 3, 1, 0), wantStart: at(2024, 2, 1, 0), wantEnd: at(2024, 3, 1, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := models.ReportQuery{Start: tt.start, End: tt.end, Compare: models.CompareLastYear}
+			start, end, err := comparisonRange(query)
+			if err != nil {
+				t.Fatalf("comparisonRange() error = %v", err)
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("comparisonRange() = %v - %v, want %v - %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+// describeDelta prints the percentage instead of its pointer
+func describeDelta(delta models.MetricDelta) string {
+	pct := "nil"
+	if delta.ChangePct != nil {
+		pct = fmt.Sprint(*delta.ChangePct)
+	}
+	return fmt.Sprintf("{current %v previous %v change %v pct %s}", delta.Current, delta.Previous, delta.Change, pct)
+}
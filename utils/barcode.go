@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ValidateBarcode checks that code is a numeric EAN-8, UPC-A or EAN-13 barcode with a valid check digit
+func ValidateBarcode(code string) error {
+	switch len(code) {
+	case 8, 12, 13:
+	default:
+		return fmt.Errorf("barcode %s must be EAN-8, UPC-A (12 digits) or EAN-13", code)
+	}
+
+	if !isDigits(code) {
+		return fmt.Errorf("barcode %s must contain digits only", code)
+	}
+
+	expected := GTINCheckDigit(code[:len(code)-1])
+	if int(code[len(code)-1]-'0') != expected {
+		return fmt.Errorf("invalid check digit for barcode %s", code)
+	}
+
+	return nil
+}
+
+// GTINCheckDigit calculates the GS1 mod-10 check digit for the given data digits
+func GTINCheckDigit(data string) int {
+	sum := 0
+	weight := 3
+	for i := len(data) - 1; i >= 0; i-- {
+		sum += int(data[i]-'0') * weight
+		weight = 4 - weight // alternate 3, 1, 3, 1...
+	}
+	return (10 - sum%10) % 10
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// code128Patterns holds the bar/space widths for Code 128 symbol values 0-106
+var code128Patterns = []string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = 106
+)
+
+// EncodeCode128 encodes printable ASCII data using Code 128 set B and returns the bar modules (true = bar)
+func EncodeCode128(data string) ([]bool, error) {
+	if data == "" {
+		return nil, fmt.Errorf("barcode data cannot be empty")
+	}
+
+	symbols := []int{code128StartB}
+	checksum := code128StartB
+	for i, ch := range data {
+		if ch < 32 || ch > 126 {
+			return nil, fmt.Errorf("character %q cannot be encoded in Code 128", ch)
+		}
+		value := int(ch) - 32
+		symbols = append(symbols, value)
+		checksum += value * (i + 1)
+	}
+	symbols = append(symbols, checksum%103, code128Stop)
+
+	var modules []bool
+	for _, symbol := range symbols {
+		bar := true
+		for _, width := range code128Patterns[symbol] {
+			for n := 0; n < int(width-'0'); n++ {
+				modules = append(modules, bar)
+			}
+			bar = !bar
+		}
+	}
+
+	return modules, nil
+}
+
+// ean13LeftCodes are the L-code (odd parity) patterns for digits 0-9
+var ean13LeftCodes = []string{
+	"0001101", "0011001", "0010011", "0111101", "0100011",
+	"0110001", "0101111", "0111011", "0110111", "0001011",
+}
+
+// ean13Parity selects L or G encoding for the left half based on the first digit
+var ean13Parity = []string{
+	"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG",
+	"LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL",
+}
+
+// EncodeEAN13 encodes a valid 13-digit EAN and returns its 95 bar modules (true = bar)
+func EncodeEAN13(code string) ([]bool, error) {
+	if len(code) != 13 {
+		return nil, fmt.Errorf("EAN-13 barcode must have 13 digits")
+	}
+	if err := ValidateBarcode(code); err != nil {
+		return nil, err
+	}
+
+	pattern := "101"
+	parity := ean13Parity[code[0]-'0']
+	for i := 1; i <= 6; i++ {
+		left := ean13LeftCodes[code[i]-'0']
+		if parity[i-1] == 'G' {
+			left = reverseString(invertBits(left))
+		}
+		pattern += left
+	}
+	pattern += "01010"
+	for i := 7; i <= 12; i++ {
+		pattern += invertBits(ean13LeftCodes[code[i]-'0'])
+	}
+	pattern += "101"
+
+	modules := make([]bool, len(pattern))
+	for i, bit := range pattern {
+		modules[i] = bit == '1'
+	}
+
+	return modules, nil
+}
+
+func invertBits(bits string) string {
+	out := []byte(bits)
+	for i, b := range out {
+		if b == '0' {
+			out[i] = '1'
+		} else {
+			out[i] = '0'
+		}
+	}
+	return string(out)
+}
+
+func reverseString(s string) string {
+	out := []byte(s)
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// IsScaleBarcode reports whether code is an in-store EAN-13 with a prefix from 20 to 29
+func IsScaleBarcode(code string) bool {
+	return len(code) == 13 && isDigits(code) && code[0] == '2'
+}
+
+// DecodeScaleBarcode extracts the PLU and embedded value from a scale-printed EAN-13.
+// The PLU follows the 2-digit prefix and the value ends right before the check digit.
+func DecodeScaleBarcode(code string, pluLength, valueLength, valueDecimals int) (string, float64, error) {
+	if err := ValidateBarcode(code); err != nil {
+		return "", 0, err
+	}
+
+	if 2+pluLength+valueLength > 12 {
+		return "", 0, fmt.Errorf("scale barcode rule does not fit in 13 digits")
+	}
+
+	plu := strings.TrimLeft(code[2:2+pluLength], "0")
+
+	raw, err := strconv.Atoi(code[12-valueLength : 12])
+	if err != nil {
+		return "", 0, err
+	}
+
+	return plu, float64(raw) / math.Pow(10, float64(valueDecimals)), nil
+}
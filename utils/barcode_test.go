@@ -0,0 +1,207 @@
+package utils
+
+import "testing"
+
+func TestGTINCheckDigit(t *testing.T) {
+	tests := []struct {
+		data string
+		want int
+	}{
+		{data: "400638133393", want: 1}, // EAN-13
+		{data: "9638507", want: 4},      // EAN-8
+		{data: "03600029145", want: 2},  // UPC-A
+		{data: "200012301250", want: 6}, // In-store scale code
+		{data: "000000000000", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			if got := GTINCheckDigit(tt.data); got != tt.want {
+				t.Errorf("GTINCheckDigit(%q) = %d, want %d", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBarcode(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		wantErr bool
+	}{
+		{name: "EAN-13", code: "4006381333931"},
+		{name: "EAN-8", code: "96385074"},
+		{name: "UPC-A", code: "036000291452"},
+		{name: "wrong check digit", code: "4006381333932", wantErr: true},
+		{name: "wrong UPC-A check digit", code: "036000291453", wantErr: true},
+		{name: "letters", code: "40063813339A", wantErr: true},
+		{name: "too short", code: "12345", wantErr: true},
+		{name: "14 digits", code: "04006381333931", wantErr: true},
+		{name: "empty", code: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBarcode(tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBarcode(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeScaleBarcode(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          string
+		pluLength     int
+		valueLength   int
+		valueDecimals int
+		wantPLU       string
+		wantValue     float64
+		wantErr       bool
+	}{
+		{name: "weight in grams", code: "2000123012506", pluLength: 5, valueLength: 5, valueDecimals: 3, wantPLU: "123", wantValue: 1.25},
+		{name: "whole rupiah price", code: "2100045012346", pluLength: 5, valueLength: 5, wantPLU: "45", wantValue: 1234},
+		{name: "short PLU, long value", code: "2712340000159", pluLength: 4, valueLength: 6, valueDecimals: 2, wantPLU: "1234", wantValue: 0.15},
+		{name: "zero value", code: "2000123000008", pluLength: 5, valueLength: 5, valueDecimals: 3, wantPLU: "123", wantValue: 0},
+		{name: "wrong check digit", code: "2000123012507", pluLength: 5, valueLength: 5, valueDecimals: 3, wantErr: true},
+		{name: "rule longer than the code", code: "2000123012506", pluLength: 6, valueLength: 6, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plu, value, err := DecodeScaleBarcode(tt.code, tt.pluLength, tt.valueLength, tt.valueDecimals)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeScaleBarcode(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if plu != tt.wantPLU || value != tt.wantValue {
+				t.Errorf("DecodeScaleBarcode(%q) = %q, %v, want %q, %v", tt.code, plu, value, tt.wantPLU, tt.wantValue)
+			}
+		})
+	}
+}
+
+// modulesString renders barcode modules as 1 for a bar and 0 for a space
+func modulesString(modules []bool) string {
+	out := make([]byte, len(modules))
+	for i, bar := range modules {
+		out[i] = '0'
+		if bar {
+			out[i] = '1'
+		}
+	}
+	return string(out)
+}
+
+// code128Symbol returns the value of the symbol at the given position, or -1 when its modules match no pattern
+func code128Symbol(modules string, position int) int {
+	symbol := modules[position*11 : position*11+11]
+	for value, widths := range code128Patterns {
+		var pattern []byte
+		bar := byte('1')
+		for _, width := range widths {
+			for n := 0; n < int(width-'0'); n++ {
+				pattern = append(pattern, bar)
+			}
+			bar = '1' + '0' - bar
+		}
+		if string(pattern) == symbol {
+			return value
+		}
+	}
+	return -1
+}
+
+func TestEncodeCode128(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantChecksum int
+		wantErr      bool
+	}{
+		{name: "single character", data: "A", wantChecksum: 34},
+		{name: "mixed case", data: "Wikipedia", wantChecksum: 88},
+		{name: "SKU with dash", data: "SKU-001", wantChecksum: (104 + 51 + 43*2 + 53*3 + 13*4 + 16*5 + 16*6 + 17*7) % 103},
+		{name: "empty", data: "", wantErr: true},
+		{name: "non-ASCII", data: "café", wantErr: true},
+		{name: "control character", data: "A\tB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modules, err := EncodeCode128(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeCode128(%q) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// Start B, one symbol per character, checksum and the 13 module stop pattern
+			got := modulesString(modules)
+			if want := 11*(len(tt.data)+2) + 13; len(got) != want {
+				t.Fatalf("EncodeCode128(%q) has %d modules, want %d", tt.data, len(got), want)
+			}
+			if got[:11] != "11010010000" {
+				t.Errorf("EncodeCode128(%q) starts with %s, want start B", tt.data, got[:11])
+			}
+			if got[len(got)-13:] != "1100011101011" {
+				t.Errorf("EncodeCode128(%q) ends with %s, want the stop pattern", tt.data, got[len(got)-13:])
+			}
+			for i, ch := range tt.data {
+				if symbol := code128Symbol(got, i+1); symbol != int(ch)-32 {
+					t.Errorf("EncodeCode128(%q) symbol %d = %d, want %d", tt.data, i+1, symbol, int(ch)-32)
+				}
+			}
+			if checksum := code128Symbol(got, len(tt.data)+1); checksum != tt.wantChecksum {
+				t.Errorf("EncodeCode128(%q) checksum = %d, want %d", tt.data, checksum, tt.wantChecksum)
+			}
+		})
+	}
+}
+
+func TestEncodeEAN13(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{
+			// First digit 5 selects the LGGLLG parity for the left half
+			name: "mixed parity",
+			code: "5901234123457",
+			want: "101" + "0001011" + "0100111" + "0110011" + "0010011" + "0111101" + "0011101" +
+				"01010" + "1100110" + "1101100" + "1000010" + "1011100" + "1001110" + "1000100" + "101",
+		},
+		{
+			// First digit 0 encodes the left half with L codes only, like UPC-A
+			name: "all odd parity",
+			code: "0036000291452",
+			want: "101" + "0001101" + "0111101" + "0101111" + "0001101" + "0001101" + "0001101" +
+				"01010" + "1101100" + "1110100" + "1100110" + "1011100" + "1001110" + "1101100" + "101",
+		},
+		{name: "wrong check digit", code: "5901234123458", wantErr: true},
+		{name: "EAN-8", code: "96385074", wantErr: true},
+		{name: "letters", code: "590123412345A", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modules, err := EncodeEAN13(tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeEAN13(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := modulesString(modules); got != tt.want {
+				t.Errorf("EncodeEAN13(%q) =\n%s\nwant\n%s", tt.code, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+// DateRange is a half-open [Start, End) interval
+type DateRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ParseDateRange turns inclusive YYYY-MM-DD dates into [start of startDate, start of the day after
+// endDate) in location. A missing end defaults to today and a missing start to defaultDays days
+// before the end, so defaultDays = 1 means a single day.
+func ParseDateRange(startDate, endDate string, location *time.Location, defaultDays int) (DateRange, error) {
+	now := time.Now().In(location)
+	endDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	if endDate != "" {
+		parsed, err := time.ParseInLocation(DateLayout, endDate, location)
+		if err != nil {
+			return DateRange{}, errors.New("end_date must be a valid date in YYYY-MM-DD format")
+		}
+		endDay = parsed
+	}
+
+	startDay := endDay.AddDate(0, 0, 1-defaultDays)
+	if startDate != "" {
+		parsed, err := time.ParseInLocation(DateLayout, startDate, location)
+		if err != nil {
+			return DateRange{}, errors.New("start_date must be a valid date in YYYY-MM-DD format")
+		}
+		startDay = parsed
+	}
+
+	if startDay.After(endDay) {
+		return DateRange{}, errors.New("start_date cannot be after end_date")
+	}
+
+	return DateRange{Start: startDay, End: endDay.AddDate(0, 0, 1)}, nil
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateRange(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, wib)
+	}
+	now := time.Now().In(wib)
+	today := date(now.Year(), now.Month(), now.Day())
+
+	tests := []struct {
+		name        string
+		start, end  string
+		defaultDays int
+		want        DateRange
+		wantErr     bool
+	}{
+		{name: "both dates", start: "2024-01-01", end: "2024-01-31", defaultDays: 1, want: DateRange{date(2024, 1, 1), date(2024, 2, 1)}},
+		{name: "single day", start: "2024-02-29", end: "2024-02-29", defaultDays: 1, want: DateRange{date(2024, 2, 29), date(2024, 3, 1)}},
+		{name: "end only uses the default length", end: "2024-03-10", defaultDays: 7, want: DateRange{date(2024, 3, 4), date(2024, 3, 11)}},
+		{name: "start only ends today", start: "2024-01-01", defaultDays: 1, want: DateRange{date(2024, 1, 1), today.AddDate(0, 0, 1)}},
+		{name: "no dates is today", defaultDays: 1, want: DateRange{today, today.AddDate(0, 0, 1)}},
+		{name: "no dates with a default length", defaultDays: 30, want: DateRange{today.AddDate(0, 0, -29), today.AddDate(0, 0, 1)}},
+		{name: "start after end", start: "2024-02-01", end: "2024-01-31", defaultDays: 1, wantErr: true},
+		{name: "invalid start", start: "01-02-2024", end: "2024-01-31", defaultDays: 1, wantErr: true},
+		{name: "invalid end", start: "2024-01-01", end: "2024-02-30", defaultDays: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDateRange(tt.start, tt.end, wib, tt.defaultDays)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDateRange(%q, %q) error = %v, wantErr %v", tt.start, tt.end, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.Start.Equal(tt.want.Start) || !got.End.Equal(tt.want.End) {
+				t.Errorf("ParseDateRange(%q, %q) = [%v, %v), want [%v, %v)", tt.start, tt.end, got.Start, got.End, tt.want.Start, tt.want.End)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package utils
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ExportCSV  = "csv"
+	ExportXLSX = "xlsx"
+
+	LangID = "id"
+	LangEN = "en"
+)
+
+// Cell value types with their own number format. Plain ints and uints are written as whole
+// numbers without separators (IDs, counts), strings as text and time.Time as date-time.
+type (
+	Money    float64 // Rupiah, thousands separated without decimals
+	Quantity float64 // General format, as many decimals as it has
+	Percent  float64 // Up to 2 decimals
+)
+
+// TableWriter streams rows of a single table to a spreadsheet file
+type TableWriter interface {
+	WriteHeader(columns []string) error
+	WriteRow(values ...interface{}) error
+	Close() error
+}
+
+// NewTableWriter returns a CSV or XLSX writer with number and date formats for lang.
+// CSV for "id" uses ";" and decimal commas so spreadsheet apps set to Indonesian open it directly.
+func NewTableWriter(w io.Writer, format, lang, sheet string) (TableWriter, error) {
+	switch format {
+	case ExportCSV:
+		return newCSVTableWriter(w, lang), nil
+	case ExportXLSX:
+		return newXLSXTableWriter(w, sheet)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// ExportContentType returns the MIME type of an export format
+func ExportContentType(format string) string {
+	if format == ExportXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ==================== CSV ====================
+
+type csvTableWriter struct {
+	writer  *csv.Writer
+	lang    string
+	written int
+}
+
+func newCSVTableWriter(w io.Writer, lang string) *csvTableWriter {
+	writer := csv.NewWriter(w)
+	if lang == LangID {
+		writer.Comma = ';'
+	}
+	return &csvTableWriter{writer: writer, lang: lang}
+}
+
+func (t *csvTableWriter) WriteHeader(columns []string) error {
+	return t.writer.Write(columns)
+}
+
+func (t *csvTableWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = t.format(value)
+	}
+	if err := t.writer.Write(record); err != nil {
+		return err
+	}
+
+	// Flush regularly so large exports reach the client while rows are still being read
+	t.written++
+	if t.written%500 == 0 {
+		t.writer.Flush()
+	}
+	return t.writer.Error()
+}
+
+func (t *csvTableWriter) Close() error {
+	t.writer.Flush()
+	return t.writer.Error()
+}
+
+func (t *csvTableWriter) format(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(v)
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return escapeFormula(*v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case Money:
+		return t.decimal(math.Round(float64(v)), 0)
+	case Quantity:
+		return t.decimal(float64(v), -1)
+	case Percent:
+		return t.decimal(math.Round(float64(v)*100)/100, -1)
+	case float64:
+		return t.decimal(v, -1)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		if t.lang == LangID {
+			return v.Format("02/01/2006 15:04:05")
+		}
+		return v.Format("2006-01-02 15:04:05")
+	}
+	return escapeFormula(fmt.Sprint(value))
+}
+
+// escapeFormula prefixes text that a spreadsheet would run as a formula with a quote, so a product
+// name like "=HYPERLINK(...)" is shown as typed. Numbers are formatted by us and never escaped.
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// decimal writes a plain number, no thousands separators so spreadsheets parse it as a number
+func (t *csvTableWriter) decimal(value float64, places int) string {
+	text := strconv.FormatFloat(value, 'f', places, 64)
+	if t.lang == LangID {
+		text = strings.Replace(text, ".", ",", 1)
+	}
+	return text
+}
+
+// ==================== XLSX ====================
+
+// Style indexes in xlsxStyles
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleHeader
+	xlsxStyleMoney
+	xlsxStyleQuantity
+	xlsxStylePercent
+	xlsxStyleDateTime
+)
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="2"><numFmt numFmtId="164" formatCode="#,##0"/><numFmt numFmtId="165" formatCode="dd/mm/yyyy hh:mm:ss"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="6">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+</styleSheet>`
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// xlsxTableWriter writes a single-sheet workbook. The sheet is the last zip entry so its rows
+// can be streamed straight to the output without buffering.
+type xlsxTableWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+func newXLSXTableWriter(w io.Writer, sheet string) (*xlsxTableWriter, error) {
+	archive := zip.NewWriter(w)
+
+	var name strings.Builder
+	_ = xml.EscapeText(&name, []byte(sheetName(sheet)))
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(file, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheetWriter := bufio.NewWriter(file)
+	_, err = sheetWriter.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &xlsxTableWriter{zip: archive, sheet: sheetWriter}, nil
+}
+
+// sheetName trims a name to Excel's 31 character limit and drops characters it refuses
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "Sheet1"
+	}
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	return name
+}
+
+func (t *xlsxTableWriter) WriteHeader(columns []string) error {
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = column
+	}
+	return t.writeRow(values, xlsxStyleHeader)
+}
+
+func (t *xlsxTableWriter) WriteRow(values ...interface{}) error {
+	return t.writeRow(values, xlsxStyleDefault)
+}
+
+func (t *xlsxTableWriter) writeRow(values []interface{}, textStyle int) error {
+	t.row++
+	fmt.Fprintf(t.sheet, `<row r="%d">`, t.row)
+	for i, value := range values {
+		ref := columnName(i) + strconv.Itoa(t.row)
+		t.writeCell(ref, value, textStyle)
+	}
+	_, err := t.sheet.WriteString("</row>")
+	return err
+}
+
+func (t *xlsxTableWriter) writeCell(ref string, value interface{}, textStyle int) {
+	number := func(style int, v float64) {
+		fmt.Fprintf(t.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return
+	case *string:
+		if v != nil {
+			t.writeCell(ref, *v, textStyle)
+		}
+	case int:
+		number(xlsxStyleDefault, float64(v))
+	case int64:
+		number(xlsxStyleDefault, float64(v))
+	case uint:
+		number(xlsxStyleDefault, float64(v))
+	case Money:
+		number(xlsxStyleMoney, math.Round(float64(v)))
+	case Quantity:
+		number(xlsxStyleQuantity, float64(v))
+	case Percent:
+		number(xlsxStylePercent, math.Round(float64(v)*100)/100)
+	case float64:
+		number(xlsxStyleQuantity, v)
+	case time.Time:
+		if !v.IsZero() {
+			number(xlsxStyleDateTime, excelSerial(v))
+		}
+	default:
+		var text strings.Builder
+		_ = xml.EscapeText(&text, []byte(fmt.Sprint(value)))
+		fmt.Fprintf(t.sheet, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, textStyle, text.String())
+	}
+}
+
+func (t *xlsxTableWriter) Close() error {
+	if _, err := t.sheet.WriteString("</sheetData></worksheet>"); err != nil {
+		return err
+	}
+	if err := t.sheet.Flush(); err != nil {
+		return err
+	}
+	return t.zip.Close()
+}
+
+// columnName converts a 0-based column index to A, B, ..., Z, AA, ...
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// excelSerial converts a time to an Excel date serial using its wall clock
+func excelSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return wall.Sub(epoch).Hours() / 24
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSVTableWriterEscapesFormulas(t *testing.T) {
+	sku := "@SUM(A1)"
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{name: "plain text", value: "Indomie Goreng", want: "Indomie Goreng"},
+		{name: "equals", value: "=HYPERLINK(\"http://x\")", want: "'=HYPERLINK(\"http://x\")"},
+		{name: "plus", value: "+62 812", want: "'+62 812"},
+		{name: "minus", value: "-1+1", want: "'-1+1"},
+		{name: "at", value: &sku, want: "'@SUM(A1)"},
+		{name: "tab", value: "\t=1", want: "'\t=1"},
+		{name: "carriage return", value: "\r=1", want: "'\r=1"},
+		{name: "formula later in the text", value: "Kopi = enak", want: "Kopi = enak"},
+		{name: "empty", value: "", want: ""},
+		{name: "negative money stays a number", value: Money(-1500), want: "-1500"},
+		{name: "negative quantity stays a number", value: Quantity(-2.5), want: "-2,5"},
+		{name: "negative int stays a number", value: -3, want: "-3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := newCSVTableWriter(&strings.Builder{}, LangID)
+			if got := writer.format(tt.value); got != tt.want {
+				t.Errorf("format(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+)
+
+// FormatRupiah formats an amount as Indonesian Rupiah, e.g. 12500 -> "Rp 12.500"
+func FormatRupiah(amount float64) string {
+	return "Rp " + FormatThousands(int64(math.Round(amount)))
+}
+
+// FormatThousands formats an integer using "." as the thousands separator
+func FormatThousands(n int64) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	digits := strconv.FormatInt(n, 10)
+	out := make([]byte, 0, len(digits)+len(digits)/3)
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out = append(out, '.')
+		}
+		out = append(out, digits[i])
+	}
+
+	return sign + string(out)
+}
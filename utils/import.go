@@ -0,0 +1,296 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Table holds the rows of an imported file as text
+type Table struct {
+	Rows    [][]string
+	numbers map[[2]int]bool // XLSX cells stored as numbers, their text is a plain machine number
+}
+
+// IsNumber reports whether a cell was stored as a number, so its text needs no locale guessing
+func (t *Table) IsNumber(row, column int) bool {
+	return t.numbers[[2]int{row, column}]
+}
+
+// ReadTable reads all rows of a CSV file or the first sheet of an XLSX workbook as text.
+// CSV files may be separated by "," or ";", whichever the header line uses most.
+func ReadTable(data []byte, format string) (*Table, error) {
+	switch format {
+	case ExportCSV:
+		rows, err := readCSVTable(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Table{Rows: rows}, nil
+	case ExportXLSX:
+		return readXLSXTable(data)
+	}
+	return nil, fmt.Errorf("unsupported import format %q", format)
+}
+
+// ImportFormat returns the table format of a file name, or "" when it is neither CSV nor XLSX
+func ImportFormat(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return ExportCSV
+	case ".xlsx":
+		return ExportXLSX
+	}
+	return ""
+}
+
+// ParseDecimal parses a number written with either decimal point or decimal comma.
+// With both separators the last one is the decimal separator, a separator that occurs
+// more than once is a thousands separator, and a single one is the decimal separator.
+func ParseDecimal(text string) (float64, error) {
+	text = strings.ReplaceAll(strings.TrimSpace(text), " ", "")
+
+	lastDot, lastComma := strings.LastIndex(text, "."), strings.LastIndex(text, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastComma > lastDot {
+			text = strings.ReplaceAll(text, ".", "")
+			text = strings.Replace(text, ",", ".", 1)
+		} else {
+			text = strings.ReplaceAll(text, ",", "")
+		}
+	case strings.Count(text, ",") > 1:
+		text = strings.ReplaceAll(text, ",", "")
+	case strings.Count(text, ".") > 1:
+		text = strings.ReplaceAll(text, ".", "")
+	case lastComma >= 0:
+		text = strings.Replace(text, ",", ".", 1)
+	}
+
+	return strconv.ParseFloat(text, 64)
+}
+
+// ParseAmount parses a money amount such as "Rp 15.000" or "12,500.50". It reads numbers like
+// ParseDecimal, except that a single separator followed by exactly three digits is a thousands
+// separator, so "15.000" and "15,000" are both 15000 and never 15.
+func ParseAmount(text string) (float64, error) {
+	text = strings.ReplaceAll(strings.TrimSpace(text), " ", "")
+	if len(text) >= 2 && strings.EqualFold(text[:2], "rp") {
+		text = text[2:]
+	}
+
+	if strings.Count(text, ".")+strings.Count(text, ",") == 1 {
+		separator := strings.IndexAny(text, ".,")
+		if len(text)-separator-1 == 3 {
+			text = text[:separator] + text[separator+1:]
+		}
+	}
+
+	return ParseDecimal(text)
+}
+
+func readCSVTable(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Byte order mark added by Excel
+
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
+
+// ==================== XLSX ====================
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelationshipID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxText is a shared or inline string, either plain or made of formatted runs
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var text strings.Builder
+	for _, run := range t.Runs {
+		text.WriteString(run.Text)
+	}
+	return text.String()
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSXTable(data []byte) (*Table, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a valid xlsx file: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var shared []string
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		var table struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := decodeZipXML(file, &table); err != nil {
+			return nil, err
+		}
+		for _, item := range table.Items {
+			shared = append(shared, item.String())
+		}
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	file, ok := files[sheetPath]
+	if !ok {
+		return nil, errors.New("xlsx file has no worksheet")
+	}
+
+	var sheet xlsxSheet
+	if err := decodeZipXML(file, &sheet); err != nil {
+		return nil, err
+	}
+
+	table := &Table{numbers: make(map[[2]int]bool)}
+	for i, row := range sheet.Rows {
+		// Empty rows are left out of the file, keep them so row numbers match the sheet
+		number := row.Number
+		if number == 0 {
+			number = i + 1
+		}
+		for len(table.Rows) < number-1 {
+			table.Rows = append(table.Rows, nil)
+		}
+
+		var record []string
+		for j, cell := range row.Cells {
+			column := j
+			if cell.Ref != "" {
+				column = columnIndex(cell.Ref)
+			}
+			for len(record) <= column {
+				record = append(record, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared) {
+					return nil, fmt.Errorf("cell %s refers to a missing shared string", cell.Ref)
+				}
+				record[column] = shared[index]
+			case "inlineStr":
+				record[column] = cell.Inline.String()
+			case "", "n":
+				record[column] = cell.Value
+				table.numbers[[2]int{len(table.Rows), column}] = cell.Value != ""
+			default:
+				record[column] = cell.Value
+			}
+		}
+		table.Rows = append(table.Rows, record)
+	}
+
+	return table, nil
+}
+
+// firstSheetPath finds the part of the first sheet through the workbook relationships
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	workbookFile, ok := files["xl/workbook.xml"]
+	relsFile, hasRels := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !hasRels {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(workbookFile, &workbook); err != nil {
+		return "", err
+	}
+	var rels xlsxRelationships
+	if err := decodeZipXML(relsFile, &rels); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", errors.New("xlsx file has no worksheet")
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].RelationshipID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return "", errors.New("xlsx file has no worksheet")
+}
+
+func decodeZipXML(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(reader, 64<<20)).Decode(v); err != nil {
+		return fmt.Errorf("invalid %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// columnIndex converts the letters of a cell reference such as "AB12" to a 0-based column
+func columnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+	}
+	return index - 1
+}
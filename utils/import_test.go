@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    float64
+		wantErr bool
+	}{
+		{text: "12.5", want: 12.5},
+		{text: "12,5", want: 12.5},
+		{text: "1.234,56", want: 1234.56},
+		{text: "1,234.56", want: 1234.56},
+		{text: "1.234.567", want: 1234567},
+		{text: "1,234,567", want: 1234567},
+		{text: " 3 000 ", want: 3000},
+		{text: "15.000", want: 15}, // A single separator is decimal, amounts use ParseAmount
+		{text: "0", want: 0},
+		{text: "", wantErr: true},
+		{text: "abc", wantErr: true},
+		{text: "1,2,3.4.5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseDecimal(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDecimal(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseDecimal(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    float64
+		wantErr bool
+	}{
+		{text: "15.000", want: 15000},
+		{text: "15,000", want: 15000},
+		{text: "Rp 15.000", want: 15000},
+		{text: "rp12.500,50", want: 12500.5},
+		{text: "12,500.50", want: 12500.5},
+		{text: "1.250.000", want: 1250000},
+		{text: "12,5", want: 12.5},
+		{text: "12.50", want: 12.5},
+		{text: "8000", want: 8000},
+		{text: "8000.125", want: 8000125}, // Three digits after one separator are thousands
+		{text: "Rp", wantErr: true},
+		{text: "gratis", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseAmount(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAmount(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseAmount(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSVTable(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{
+			name: "comma",
+			data: "name,price\nKopi,15000\n",
+			want: [][]string{{"name", "price"}, {"Kopi", "15000"}},
+		},
+		{
+			name: "semicolon",
+			data: "nama;harga\nKopi;15.000\n",
+			want: [][]string{{"nama", "harga"}, {"Kopi", "15.000"}},
+		},
+		{
+			name: "semicolon with decimal commas in the rows",
+			data: "nama;harga;stok\nGula;12,5;1,250\n",
+			want: [][]string{{"nama", "harga", "stok"}, {"Gula", "12,5", "1,250"}},
+		},
+		{
+			name: "byte order mark",
+			data: "\xef\xbb\xbfnama;harga\nTeh;5000\n",
+			want: [][]string{{"nama", "harga"}, {"Teh", "5000"}},
+		},
+		{
+			name: "windows line endings",
+			data: "name,price\r\nTeh,5000\r\n",
+			want: [][]string{{"name", "price"}, {"Teh", "5000"}},
+		},
+		{
+			name: "ragged rows",
+			data: "name,price,sku\nTeh\n",
+			want: [][]string{{"name", "price", "sku"}, {"Teh"}},
+		},
+		{
+			name: "single column",
+			data: "name\nTeh\n",
+			want: [][]string{{"name"}, {"Teh"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCSVTable([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("readCSVTable() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readCSVTable() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Label is a single shelf label ready to be rendered
+type Label struct {
+	Name  string
+	Price string
+	Code  string // Human readable text printed under the barcode
+	Bars  []bool // Barcode modules, true = bar. Empty means no barcode
+}
+
+// LabelLayout describes how labels are arranged on an A4 sheet
+type LabelLayout struct {
+	Columns int
+	Rows    int
+}
+
+const (
+	pageWidthMM  = 210.0
+	pageHeightMM = 297.0
+	pageMarginMM = 5.0
+	mmToPt       = 72 / 25.4
+
+	// MaxLabelRows keeps labels tall enough for the name, price and a scannable barcode
+	MaxLabelRows   = 12
+	minBarHeightMM = 4.0
+	// minModuleMM is the narrowest bar most scanners still read reliably
+	minModuleMM = 0.25
+)
+
+func (l LabelLayout) normalize() LabelLayout {
+	if l.Columns <= 0 {
+		l.Columns = 3
+	}
+	if l.Rows <= 0 {
+		l.Rows = 8
+	}
+	if l.Rows > MaxLabelRows {
+		l.Rows = MaxLabelRows
+	}
+	return l
+}
+
+// fit reduces the number of columns until the longest barcode can be printed with modules of at
+// least minModuleMM, and fails when it does not even fit a label spanning the whole page
+func (l LabelLayout) fit(labels []Label) (LabelLayout, error) {
+	longest := 0
+	for _, label := range labels {
+		if len(label.Bars) > longest {
+			longest = len(label.Bars)
+		}
+	}
+	if longest == 0 {
+		return l, nil
+	}
+
+	for ; l.Columns > 0; l.Columns-- {
+		width, _, _ := l.cell()
+		if barcodeModule(width, longest) >= minModuleMM {
+			return l, nil
+		}
+	}
+	return l, fmt.Errorf("barcode is too long to print readably on a label, shorten the code or use EAN-13")
+}
+
+// cell returns the size of one label and the number of labels per page
+func (l LabelLayout) cell() (width, height float64, perPage int) {
+	width = (pageWidthMM - 2*pageMarginMM) / float64(l.Columns)
+	height = (pageHeightMM - 2*pageMarginMM) / float64(l.Rows)
+	return width, height, l.Columns * l.Rows
+}
+
+// labelBox is the position of a label in millimetres from the top-left corner of its page
+type labelBox struct {
+	page          int
+	x, y          float64
+	width, height float64
+}
+
+func (l LabelLayout) boxes(count int) []labelBox {
+	width, height, perPage := l.cell()
+	boxes := make([]labelBox, count)
+	for i := range boxes {
+		slot := i % perPage
+		boxes[i] = labelBox{
+			page:   i / perPage,
+			x:      pageMarginMM + float64(slot%l.Columns)*width,
+			y:      pageMarginMM + float64(slot/l.Columns)*height,
+			width:  width,
+			height: height,
+		}
+	}
+	return boxes
+}
+
+// truncateLabelText shortens text so it fits the given width at the given font size (both in mm)
+func truncateLabelText(text string, widthMM, fontMM float64) string {
+	maxChars := int(widthMM / (fontMM * 0.55))
+	runes := []rune(text)
+	if len(runes) <= maxChars || maxChars < 4 {
+		return text
+	}
+	return string(runes[:maxChars-3]) + "..."
+}
+
+// RenderLabelsSVG writes all labels as a single SVG document with A4 pages stacked vertically
+func RenderLabelsSVG(w io.Writer, labels []Label, layout LabelLayout) error {
+	layout, err := layout.normalize().fit(labels)
+	if err != nil {
+		return err
+	}
+	boxes := layout.boxes(len(labels))
+
+	pages := 1
+	if len(boxes) > 0 {
+		pages = boxes[len(boxes)-1].page + 1
+	}
+	totalHeight := pageHeightMM * float64(pages)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0fmm" height="%.0fmm" viewBox="0 0 %.0f %.0f" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		pageWidthMM, totalHeight, pageWidthMM, totalHeight)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+
+	for i, label := range labels {
+		box := boxes[i]
+		top := float64(box.page)*pageHeightMM + box.y
+		name := truncateLabelText(label.Name, box.width-4, 3.2)
+
+		fmt.Fprintf(&buf, `<g transform="translate(%.2f %.2f)">`+"\n", box.x, top)
+		fmt.Fprintf(&buf, `<rect x="0.5" y="0.5" width="%.2f" height="%.2f" fill="none" stroke="#999" stroke-width="0.2" stroke-dasharray="1 1"/>`+"\n", box.width-1, box.height-1)
+		fmt.Fprintf(&buf, `<text x="2" y="5.5" font-size="3.2">%s</text>`+"\n", html.EscapeString(name))
+		fmt.Fprintf(&buf, `<text x="2" y="12" font-size="5.5" font-weight="bold">%s</text>`+"\n", html.EscapeString(label.Price))
+
+		if len(label.Bars) > 0 {
+			barX, barWidth, module := barcodeArea(box.width, len(label.Bars))
+			barTop, barHeight := 14.0, labelBarHeight(box.height)
+			for j, bar := range label.Bars {
+				if bar {
+					fmt.Fprintf(&buf, `<rect x="%.3f" y="%.2f" width="%.3f" height="%.2f" fill="black"/>`+"\n",
+						barX+float64(j)*module, barTop, module, barHeight)
+				}
+			}
+			fmt.Fprintf(&buf, `<text x="%.2f" y="%.2f" font-size="2.6" text-anchor="middle">%s</text>`+"\n",
+				barX+barWidth/2, barTop+barHeight+3, html.EscapeString(label.Code))
+		}
+
+		buf.WriteString("</g>\n")
+	}
+
+	buf.WriteString("</svg>\n")
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// labelBarHeight is the barcode height for a label of the given height, below the name and price and
+// above the code text
+func labelBarHeight(labelHeight float64) float64 {
+	height := labelHeight - 20
+	if height < minBarHeightMM {
+		height = minBarHeightMM
+	}
+	return height
+}
+
+// barcodeArea centres a barcode of the given module count inside a label of the given width
+func barcodeArea(labelWidth float64, modules int) (x, width, module float64) {
+	module = barcodeModule(labelWidth, modules)
+	if module > 0.33 {
+		module = 0.33
+	}
+	width = module * float64(modules)
+	x = (labelWidth - width) / 2
+	return x, width, module
+}
+
+// barcodeModule is the widest module that fits the given module count into a label, leaving quiet
+// zones on both sides
+func barcodeModule(labelWidth float64, modules int) float64 {
+	return (labelWidth - 6) / float64(modules)
+}
+
+// RenderLabelsPDF writes all labels as an A4 PDF using the built-in Helvetica font
+func RenderLabelsPDF(w io.Writer, labels []Label, layout LabelLayout) error {
+	layout, err := layout.normalize().fit(labels)
+	if err != nil {
+		return err
+	}
+	boxes := layout.boxes(len(labels))
+
+	pages := 1
+	if len(boxes) > 0 {
+		pages = boxes[len(boxes)-1].page + 1
+	}
+
+	contents := make([]bytes.Buffer, pages)
+	for i, label := range labels {
+		box := boxes[i]
+		content := &contents[box.page]
+
+		// PDF origin is bottom-left, so flip the y axis
+		left := box.x * mmToPt
+		top := (pageHeightMM - box.y) * mmToPt
+		name := truncateLabelText(label.Name, box.width-4, 3.2)
+
+		fmt.Fprintf(content, "0.6 G 0.5 w %.2f %.2f %.2f %.2f re S\n",
+			left+1.4, top-box.height*mmToPt+1.4, (box.width-1)*mmToPt, (box.height-1)*mmToPt)
+		fmt.Fprintf(content, "0 g BT /F1 9 Tf %.2f %.2f Td (%s) Tj ET\n", left+2*mmToPt, top-5.5*mmToPt, pdfEscape(name))
+		fmt.Fprintf(content, "BT /F2 15 Tf %.2f %.2f Td (%s) Tj ET\n", left+2*mmToPt, top-12*mmToPt, pdfEscape(label.Price))
+
+		if len(label.Bars) > 0 {
+			barX, barWidth, module := barcodeArea(box.width, len(label.Bars))
+			barTop, barHeight := 14.0, labelBarHeight(box.height)
+			for j, bar := range label.Bars {
+				if bar {
+					fmt.Fprintf(content, "%.3f %.3f %.3f %.3f re f\n",
+						left+(barX+float64(j)*module)*mmToPt, top-(barTop+barHeight)*mmToPt, module*mmToPt, barHeight*mmToPt)
+				}
+			}
+			textWidth := float64(len(label.Code)) * 7 * 0.5
+			fmt.Fprintf(content, "BT /F1 7 Tf %.2f %.2f Td (%s) Tj ET\n",
+				left+(barX+barWidth/2)*mmToPt-textWidth/2, top-(barTop+barHeight+3)*mmToPt, pdfEscape(label.Code))
+		}
+	}
+
+	// Objects: 1 catalog, 2 page tree, 3-4 fonts, then a page and content stream per page
+	var objects []string
+	kids := make([]string, pages)
+	for i := range kids {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	)
+	for i := range contents {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pageWidthMM*mmToPt, pageHeightMM*mmToPt, 6+i*2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", contents[i].Len(), contents[i].String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// pdfEscape escapes a string for a PDF literal and replaces characters outside Latin-1
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r > 255:
+			b.WriteByte('?')
+		case r > 126:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
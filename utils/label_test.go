@@ -0,0 +1,32 @@
+package utils
+
+import "testing"
+
+func TestLabelLayoutFit(t *testing.T) {
+	tests := []struct {
+		name        string
+		layout      LabelLayout
+		modules     int
+		wantColumns int
+		wantErr     bool
+	}{
+		{name: "EAN-13 fits six columns", layout: LabelLayout{Columns: 6, Rows: 8}, modules: 95, wantColumns: 6},
+		{name: "long Code 128 drops columns", layout: LabelLayout{Columns: 6, Rows: 8}, modules: 200, wantColumns: 3},
+		{name: "falls back to one column", layout: LabelLayout{Columns: 3, Rows: 8}, modules: 700, wantColumns: 1},
+		{name: "too long for a full-width label", layout: LabelLayout{Columns: 3, Rows: 8}, modules: 800, wantErr: true},
+		{name: "labels without barcode", layout: LabelLayout{Columns: 6, Rows: 8}, wantColumns: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := []Label{{Name: "Short"}, {Name: "Long", Bars: make([]bool, tt.modules)}}
+			got, err := tt.layout.fit(labels)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Columns != tt.wantColumns {
+				t.Errorf("fit() columns = %d, want %d", got.Columns, tt.wantColumns)
+			}
+		})
+	}
+}
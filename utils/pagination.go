@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"Kasir-API/models"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	cursorAfterPrefix  = "k:"
+	cursorBeforePrefix = "b:"
+)
+
+var (
+	ErrInvalidCursor = errors.New("cursor is invalid")
+	ErrStaleCursor   = errors.New("cursor points at a row that no longer exists, start again from the first page")
+)
+
+// PageMeta is returned in the meta field of every paginated list
+type PageMeta struct {
+	Page       int     `json:"page,omitempty"` // Not set when paging with a cursor
+	Limit      int     `json:"limit"`
+	Total      int64   `json:"total"`
+	TotalPages int     `json:"total_pages"`
+	Next       *string `json:"next"`
+	Prev       *string `json:"prev"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+}
+
+// ParsePagination reads ?page=&limit= or ?cursor=&limit= from the request.
+// A cursor takes precedence over page. Limits above MaxPageSize are capped.
+// A cursor continues after the last row of the previous page, or before the first row of the
+// next one when it came from a prev_cursor.
+func ParsePagination(c *gin.Context) (models.Page, error) {
+	page := models.Page{Limit: DefaultPageSize}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			return page, errors.New("limit must be a positive integer")
+		}
+		page.Limit = value
+	}
+	if page.Limit > MaxPageSize {
+		page.Limit = MaxPageSize
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		id, backward, err := DecodeCursor(cursor)
+		if err != nil {
+			return page, err
+		}
+		if backward {
+			page.Before = id
+		} else {
+			page.After = id
+		}
+		return page, nil
+	}
+
+	if number := c.Query("page"); number != "" {
+		value, err := strconv.Atoi(number)
+		if err != nil || value < 1 {
+			return page, errors.New("page must be a positive integer")
+		}
+		page.Offset = (value - 1) * page.Limit
+	}
+
+	return page, nil
+}
+
+// EncodeCursor turns a row ID into an opaque cursor token. A forward cursor continues after the
+// row, a backward one ends right before it.
+func EncodeCursor(id uint, backward bool) string {
+	prefix := cursorAfterPrefix
+	if backward {
+		prefix = cursorBeforePrefix
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(prefix + strconv.FormatUint(uint64(id), 10)))
+}
+
+// DecodeCursor reads the row ID and direction back from a cursor token
+func DecodeCursor(cursor string) (id uint, backward bool, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false, ErrInvalidCursor
+	}
+
+	var value string
+	switch {
+	case strings.HasPrefix(string(raw), cursorAfterPrefix):
+		value = strings.TrimPrefix(string(raw), cursorAfterPrefix)
+	case strings.HasPrefix(string(raw), cursorBeforePrefix):
+		value, backward = strings.TrimPrefix(string(raw), cursorBeforePrefix), true
+	default:
+		return 0, false, ErrInvalidCursor
+	}
+
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || parsed == 0 {
+		return 0, false, ErrInvalidCursor
+	}
+	return uint(parsed), backward, nil
+}
+
+// NewPageMeta builds the meta block with links to the neighbouring pages. Links keep the
+// request's other query parameters and follow the style the client used (cursor or page).
+// firstID and lastID are the IDs of the first and last row on the page, the cursors continue
+// before and after them.
+func NewPageMeta(c *gin.Context, page models.Page, info models.PageInfo, count int, firstID, lastID uint) PageMeta {
+	meta := PageMeta{
+		Limit: page.Limit,
+		Total: info.Total,
+	}
+	meta.TotalPages = int((info.Total + int64(page.Limit) - 1) / int64(page.Limit))
+
+	if page.After != 0 || page.Before != 0 {
+		// The position of a cursor page is unknown. HasMore tells whether rows follow in the
+		// direction it was read, the other side holds at least the cursor's own row.
+		if count == 0 {
+			return meta
+		}
+		if page.Before != 0 || info.HasMore {
+			cursor := EncodeCursor(lastID, false)
+			link := cursorLink(c, page, cursor)
+			meta.NextCursor, meta.Next = &cursor, &link
+		}
+		if page.After != 0 || info.HasMore {
+			cursor := EncodeCursor(firstID, true)
+			link := cursorLink(c, page, cursor)
+			meta.PrevCursor, meta.Prev = &cursor, &link
+		}
+		return meta
+	}
+
+	meta.Page = page.Offset/page.Limit + 1
+
+	if info.HasMore {
+		if lastID != 0 {
+			cursor := EncodeCursor(lastID, false)
+			meta.NextCursor = &cursor
+		}
+		link := pageLink(c, page, meta.Page+1)
+		meta.Next = &link
+	}
+
+	if page.Offset > 0 {
+		if firstID != 0 {
+			cursor := EncodeCursor(firstID, true)
+			meta.PrevCursor = &cursor
+		}
+		link := pageLink(c, page, meta.Page-1)
+		meta.Prev = &link
+	}
+
+	return meta
+}
+func pageLink(c *gin.Context, page models.Page, number int) string {
+	query := c.Request.URL.Query()
+	query.Set("limit", strconv.Itoa(page.Limit))
+	query.Del("cursor")
+	query.Set("page", strconv.Itoa(number))
+	return c.Request.URL.Path + "?" + query.Encode()
+}
+
+func cursorLink(c *gin.Context, page models.Page, cursor string) string {
+	query := c.Request.URL.Query()
+	query.Set("limit", strconv.Itoa(page.Limit))
+	query.Del("page")
+	query.Set("cursor", cursor)
+	return c.Request.URL.Path + "?" + query.Encode()
+}
+
+// rowID returns the ID field of the element at index in a slice of rows, or 0
+func rowID(rows reflect.Value, index int) uint {
+	row := reflect.Indirect(rows.Index(index))
+	if row.Kind() == reflect.Interface {
+		row = reflect.Indirect(row.Elem())
+	}
+	if row.Kind() != reflect.Struct {
+		return 0
+	}
+	id := row.FieldByName("ID")
+	if !id.IsValid() || id.Kind() != reflect.Uint {
+		return 0
+	}
+	return uint(id.Uint())
+}
+
+// Paginated sends a list response with pagination meta
+func Paginated(c *gin.Context, message string, data interface{}, page models.Page, info models.PageInfo) {
+	var count int
+	var firstID, lastID uint
+	if rows := reflect.ValueOf(data); rows.Kind() == reflect.Slice && rows.Len() > 0 {
+		count = rows.Len()
+		firstID, lastID = rowID(rows, 0), rowID(rows, count-1)
+	}
+	SuccessWithMeta(c, message, data, NewPageMeta(c, page, info, count, firstID, lastID))
+}
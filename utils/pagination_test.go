@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"Kasir-API/models"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       uint
+		backward bool
+	}{
+		{name: "forward", id: 42},
+		{name: "backward", id: 42, backward: true},
+		{name: "large ID", id: 1<<32 + 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, backward, err := DecodeCursor(EncodeCursor(tt.id, tt.backward))
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+			if id != tt.id || backward != tt.backward {
+				t.Errorf("DecodeCursor() = %d, %v, want %d, %v", id, backward, tt.id, tt.backward)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, cursor := range []string{"", "not base64!", "eDo0Mg", "azow", "azphYmM"} { // x:42, k:0, k:abc
+		if _, _, err := DecodeCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestNewPageMeta(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		page      models.Page
+		info      models.PageInfo
+		count     int
+		wantNext  bool
+		wantPrev  bool
+		wantPage  int
+		wantPages int
+	}{
+		{name: "first page", page: models.Page{Limit: 2}, info: models.PageInfo{Total: 5, HasMore: true}, count: 2, wantNext: true, wantPage: 1, wantPages: 3},
+		{name: "last page", page: models.Page{Limit: 2, Offset: 4}, info: models.PageInfo{Total: 5}, count: 1, wantPrev: true, wantPage: 3, wantPages: 3},
+		{name: "forward cursor with more rows", page: models.Page{Limit: 2, After: 9}, info: models.PageInfo{Total: 5, HasMore: true}, count: 2, wantNext: true, wantPrev: true, wantPages: 3},
+		{name: "forward cursor at the end", page: models.Page{Limit: 2, After: 9}, info: models.PageInfo{Total: 5}, count: 2, wantPrev: true, wantPages: 3},
+		{name: "backward cursor with more rows", page: models.Page{Limit: 2, Before: 9}, info: models.PageInfo{Total: 5, HasMore: true}, count: 2, wantNext: true, wantPrev: true, wantPages: 3},
+		{name: "backward cursor at the start", page: models.Page{Limit: 2, Before: 9}, info: models.PageInfo{Total: 5}, count: 2, wantNext: true, wantPages: 3},
+		{name: "empty cursor page", page: models.Page{Limit: 2, After: 9}, info: models.PageInfo{Total: 5}, wantPages: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/products?name=kopi", nil)
+
+			meta := NewPageMeta(c, tt.page, tt.info, tt.count, 3, 7)
+			if (meta.Next != nil) != tt.wantNext || (meta.Prev != nil) != tt.wantPrev {
+				t.Fatalf("NewPageMeta() next = %v, prev = %v, want next %v, prev %v", meta.Next, meta.Prev, tt.wantNext, tt.wantPrev)
+			}
+			if meta.Page != tt.wantPage || meta.TotalPages != tt.wantPages {
+				t.Errorf("NewPageMeta() page = %d of %d, want %d of %d", meta.Page, meta.TotalPages, tt.wantPage, tt.wantPages)
+			}
+
+			// Cursors continue after the last row and end before the first one
+			if tt.wantNext && meta.NextCursor != nil {
+				if id, backward, _ := DecodeCursor(*meta.NextCursor); id != 7 || backward {
+					t.Errorf("next cursor = %d, backward %v, want 7 forward", id, backward)
+				}
+			}
+			if tt.wantPrev && meta.PrevCursor != nil {
+				if id, backward, _ := DecodeCursor(*meta.PrevCursor); id != 3 || !backward {
+					t.Errorf("prev cursor = %d, backward %v, want 3 backward", id, backward)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"Kasir-API/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const registerReportWidth = 40
+
+// RenderRegisterReport formats X- and Z-report totals as plain text for a receipt printer.
+// number is the Z counter and is left out for X-reports.
+func RenderRegisterReport(reportType string, number uint, totals models.RegisterTotals, location *time.Location) string {
+	var b strings.Builder
+	rule := strings.Repeat("-", registerReportWidth)
+
+	title := reportType + "-REPORT"
+	if number > 0 {
+		title += fmt.Sprintf(" #%04d", number)
+	}
+	b.WriteString(centerText(title) + "\n")
+	if reportType == models.RegisterReportX {
+		b.WriteString(centerText("(not closed)") + "\n")
+	}
+	b.WriteString(rule + "\n")
+
+	timeLayout := "02/01/2006 15:04"
+	writeRow(&b, "From", totals.PeriodStart.In(location).Format(timeLayout))
+	writeRow(&b, "To", totals.PeriodEnd.In(location).Format(timeLayout))
+	b.WriteString(rule + "\n")
+
+	writeRow(&b, "Gross sales", FormatRupiah(float64(totals.GrossSales)))
+	writeRow(&b, "Discounts", FormatRupiah(float64(-totals.Discounts)))
+	writeRow(&b, "Refunds", FormatRupiah(float64(-totals.Refunds)))
+	writeRow(&b, "Net sales", FormatRupiah(float64(totals.NetSales)))
+	writeRow(&b, "Tax incl. ("+strconv.FormatFloat(totals.TaxRate, 'f', -1, 64)+"%)", FormatRupiah(float64(totals.Tax)))
+	b.WriteString(rule + "\n")
+
+	writeRow(&b, "Transactions", strconv.Itoa(totals.TransactionCount))
+	writeRow(&b, "Items sold", strconv.FormatFloat(totals.ItemsSold, 'f', -1, 64))
+	if totals.FirstTransactionID != nil {
+		writeRow(&b, "First receipt", fmt.Sprintf("#%d %s", *totals.FirstTransactionID, totals.FirstTransactionAt.In(location).Format("15:04")))
+	}
+	if totals.LastTransactionID != nil {
+		writeRow(&b, "Last receipt", fmt.Sprintf("#%d %s", *totals.LastTransactionID, totals.LastTransactionAt.In(location).Format("15:04")))
+	}
+	b.WriteString(rule + "\n")
+
+	return b.String()
+}
+
+// writeRow writes a label on the left and a value aligned to the right edge
+func writeRow(b *strings.Builder, label, value string) {
+	padding := registerReportWidth - len(label) - len(value)
+	if padding < 1 {
+		padding = 1
+	}
+	b.WriteString(label + strings.Repeat(" ", padding) + value + "\n")
+}
+
+func centerText(text string) string {
+	if len(text) >= registerReportWidth {
+		return text
+	}
+	return strings.Repeat(" ", (registerReportWidth-len(text))/2) + text
+}
@@ -76,11 +76,28 @@ func InternalServerError(c *gin.Context, message string, err interface{}) {
 	Error(c, http.StatusInternalServerError, message, err)
 }
 
+// Conflict response (409)
+func Conflict(c *gin.Context, message string, err interface{}) {
+	Error(c, http.StatusConflict, message, err)
+}
+
 // Unauthorized response
 func Unauthorized(c *gin.Context, message string) {
 	Error(c, http.StatusUnauthorized, message, nil)
 }
 
+// IsUniqueViolation reports whether err is a duplicate key error, e.g. two products racing for the same SKU
+func IsUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	errStr := err.Error()
+	return strings.Contains(errStr, "duplicate key") ||
+		strings.Contains(errStr, "1062") || // MySQL duplicate entry
+		strings.Contains(errStr, "23505") // PostgreSQL unique violation
+}
+
 func IsForeignKeyError(err error) bool {
 	if err == nil {
 		return false